@@ -0,0 +1,168 @@
+package analysis
+
+import (
+	"math"
+	"math/cmplx"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// CorrelationMethod 互相关的加权方式
+type CorrelationMethod int
+
+const (
+	// CorrelationPlain 普通互相关，适合带宽较窄或噪声较大的信号
+	CorrelationPlain CorrelationMethod = iota
+	// CorrelationPHAT 相位变换加权的广义互相关（GCC-PHAT），峰值更尖锐，适合有混响的录音
+	CorrelationPHAT
+)
+
+// Alignment 对齐结果
+type Alignment struct {
+	// Samples 目标需要延后的样本数（负数表示需要提前），即 reference[n] ≈ target[n-Samples]
+	Samples int
+	// Offset 与 Samples 对应的时长
+	Offset time.Duration
+	// Strength 相关峰值强度（0~1），普通互相关为归一化相关系数，GCC-PHAT 为相干峰高度
+	Strength float64
+}
+
+// Align 用基于FFT的互相关计算目标相对参考的偏移，maxOffset 大于 0 时只在 ±maxOffset 范围内搜索，
+// method 省略时使用普通互相关；两段音频需采样率相同，多声道时先混合为单声道
+func Align(reference, target *audio.AudioSegment, maxOffset time.Duration, method ...CorrelationMethod) (*Alignment, error) {
+	if reference == nil || target == nil {
+		return nil, errors.New("segments cannot be nil")
+	}
+	if reference.SampleRate() != target.SampleRate() {
+		return nil, errors.New("sample rates must match")
+	}
+	if maxOffset < 0 {
+		return nil, errors.New("max offset cannot be negative")
+	}
+	m := CorrelationPlain
+	if len(method) > 0 {
+		m = method[0]
+	}
+
+	ref, tgt := monoSamples(reference), monoSamples(target)
+	if len(ref) == 0 || len(tgt) == 0 {
+		return nil, errors.New("segments cannot be empty")
+	}
+
+	correlation := crossCorrelate(ref, tgt, m)
+	n := len(correlation)
+
+	// 可搜索的延迟范围：正延迟不超过参考长度，负延迟不超过目标长度
+	maxLag, minLag := len(ref)-1, -(len(tgt) - 1)
+	if maxOffset > 0 {
+		limit := int(maxOffset.Seconds() * float64(reference.SampleRate()))
+		if limit < maxLag {
+			maxLag = limit
+		}
+		if -limit > minLag {
+			minLag = -limit
+		}
+	}
+
+	bestLag, best := 0, math.Inf(-1)
+	for lag := minLag; lag <= maxLag; lag++ {
+		if v := correlation[(lag+n)%n]; v > best {
+			bestLag, best = lag, v
+		}
+	}
+
+	strength := best
+	if m == CorrelationPlain {
+		strength /= math.Sqrt(energy(ref) * energy(tgt))
+	}
+	if math.IsNaN(strength) {
+		strength = 0
+	}
+
+	return &Alignment{
+		Samples:  bestLag,
+		Offset:   time.Duration(float64(bestLag) / float64(reference.SampleRate()) * float64(time.Second)),
+		Strength: math.Max(0, math.Min(1, strength)),
+	}, nil
+}
+
+// crossCorrelate 计算 r[lag] = Σ ref[n]·tgt[n-lag]，负延迟按循环方式存放在末尾
+func crossCorrelate(ref, tgt []float64, method CorrelationMethod) []float64 {
+	n := NextPowerOfTwo(len(ref) + len(tgt))
+	a := make([]float64, n)
+	b := make([]float64, n)
+	copy(a, ref)
+	copy(b, tgt)
+
+	specA, specB := RFFT(a), RFFT(b)
+	cross := make([]complex128, len(specA))
+	for k := range cross {
+		cross[k] = specA[k] * cmplx.Conj(specB[k])
+		if method == CorrelationPHAT {
+			if mag := cmplx.Abs(cross[k]); mag > 1e-12 {
+				cross[k] /= complex(mag, 0)
+			} else {
+				cross[k] = 0
+			}
+		}
+	}
+	return IRFFT(cross, n)
+}
+
+// energy 返回样本的能量（平方和）
+func energy(samples []float64) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += s * s
+	}
+	return sum
+}
+
+// AlignTo 将目标按对齐结果平移（前端补静音或裁掉开头），并裁剪/补齐到与参考相同的时长
+func AlignTo(reference, target *audio.AudioSegment, maxOffset time.Duration, method ...CorrelationMethod) (*audio.AudioSegment, *Alignment, error) {
+	alignment, err := Align(reference, target, maxOffset, method...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shifted, err := Shift(target, alignment.Samples, len(reference.Samples())/reference.Channels())
+	if err != nil {
+		return nil, nil, err
+	}
+	return shifted, alignment, nil
+}
+
+// Shift 将音频段延后 lag 个采样帧（负数为提前），结果长度为 frames 个采样帧，空缺部分填充静音
+func Shift(segment *audio.AudioSegment, lag, frames int) (*audio.AudioSegment, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+	if frames < 0 {
+		return nil, errors.New("frame count cannot be negative")
+	}
+
+	channels := segment.Channels()
+	source := segment.Samples()
+	sourceFrames := len(source) / channels
+	samples := make([]float64, frames*channels)
+	for i := 0; i < frames; i++ {
+		j := i - lag
+		if j < 0 || j >= sourceFrames {
+			continue
+		}
+		copy(samples[i*channels:(i+1)*channels], source[j*channels:(j+1)*channels])
+	}
+
+	shifted, err := audio.NewAudioSegment(samples, segment.SampleRate(), channels, segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	shifted = shifted.WithTags(segment.Tags())
+	// 5、7 声道等没有默认布局的音频布局为 0，保持原样即可
+	if segment.Layout() == 0 {
+		return shifted, nil
+	}
+	return shifted.WithLayout(segment.Layout())
+}
@@ -0,0 +1,1232 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"image/png"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/HiChen85/godub/pkg/stream"
+)
+
+// naiveDFT 直接按定义计算DFT，用于校验
+func naiveDFT(x []complex128) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		for t := 0; t < n; t++ {
+			out[k] += x[t] * cmplx.Rect(1, -2*math.Pi*float64(k*t)/float64(n))
+		}
+	}
+	return out
+}
+
+func TestFFT(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{1, 2, 8, 64, 12, 15, 100, 257} {
+		x := make([]complex128, n)
+		for i := range x {
+			x[i] = complex(rng.NormFloat64(), rng.NormFloat64())
+		}
+
+		expected := naiveDFT(x)
+		actual := FFT(x)
+		for k := range expected {
+			if cmplx.Abs(expected[k]-actual[k]) > 1e-9*float64(n) {
+				t.Fatalf("n=%d bin %d: expected %v, got %v", n, k, expected[k], actual[k])
+			}
+		}
+
+		restored := IFFT(actual)
+		for i := range x {
+			if cmplx.Abs(restored[i]-x[i]) > 1e-9 {
+				t.Fatalf("n=%d sample %d: expected %v, got %v", n, i, x[i], restored[i])
+			}
+		}
+	}
+}
+
+func TestRFFT(t *testing.T) {
+	for _, n := range []int{16, 30} {
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = math.Sin(float64(i)) + 0.3*math.Cos(3*float64(i))
+		}
+
+		spectrum := RFFT(x)
+		if len(spectrum) != n/2+1 {
+			t.Fatalf("expected %d bins, got %d", n/2+1, len(spectrum))
+		}
+		restored := IRFFT(spectrum, n)
+		for i := range x {
+			if math.Abs(restored[i]-x[i]) > 1e-12 {
+				t.Errorf("n=%d sample %d: expected %f, got %f", n, i, x[i], restored[i])
+			}
+		}
+	}
+}
+
+func TestWindows(t *testing.T) {
+	tests := []struct {
+		name   string
+		window WindowFunc
+	}{
+		{name: "Hann", window: Hann},
+		{name: "Hamming", window: Hamming},
+		{name: "Blackman", window: Blackman},
+		{name: "Kaiser", window: Kaiser(8.6)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.window(64)
+			if len(w) != 64 {
+				t.Fatalf("expected 64 points, got %d", len(w))
+			}
+			// 周期窗在中点取得最大值 1，并关于中点对称
+			if math.Abs(w[32]-1) > 1e-12 {
+				t.Errorf("expected peak 1 at center, got %f", w[32])
+			}
+			for i := 1; i < 32; i++ {
+				if math.Abs(w[32-i]-w[32+i]) > 1e-12 {
+					t.Errorf("window not symmetric at %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSTFTRoundTrip(t *testing.T) {
+	sampleRate := 8000
+	samples := make([]float64, 5000)
+	for i := range samples {
+		samples[i] = 0.5*math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate)) + 0.1*math.Sin(float64(i)*0.01)
+	}
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	for _, options := range []STFTOptions{
+		DefaultSTFTOptions(),
+		{FrameSize: 512, HopSize: 128},
+		{FrameSize: 500, HopSize: 125, Window: Hamming, Center: true},
+	} {
+		specs, err := STFT(segment, options)
+		if err != nil {
+			t.Fatalf("failed to compute stft: %v", err)
+		}
+
+		// 440Hz 正弦应落在对应频点上
+		spec := specs[0]
+		peak := 0
+		frame := spec.Magnitude[spec.Frames()/2]
+		for k := range frame {
+			if frame[k] > frame[peak] {
+				peak = k
+			}
+		}
+		if math.Abs(spec.BinFrequency(peak)-440) > float64(sampleRate)/float64(spec.FrameSize) {
+			t.Errorf("frame size %d: expected peak near 440Hz, got %f", spec.FrameSize, spec.BinFrequency(peak))
+		}
+
+		restored, err := ISTFT(specs, 16)
+		if err != nil {
+			t.Fatalf("failed to compute istft: %v", err)
+		}
+		for i, sample := range restored.Samples() {
+			if math.Abs(sample-samples[i]) > 1e-9 {
+				t.Fatalf("frame size %d sample %d: expected %f, got %f", spec.FrameSize, i, samples[i], sample)
+			}
+		}
+	}
+}
+
+func TestRenderSpectrogram(t *testing.T) {
+	sampleRate := 16000
+	left := make([]float64, sampleRate)
+	right := make([]float64, sampleRate)
+	for i := range left {
+		left[i] = 0.5 * math.Sin(2*math.Pi*1000*float64(i)/float64(sampleRate))
+		right[i] = 0.5 * math.Sin(2*math.Pi*4000*float64(i)/float64(sampleRate))
+	}
+	segment, err := audio.NewAudioSegmentFromChannels([][]float64{left, right}, sampleRate, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	for _, scale := range []FrequencyScale{ScaleLinear, ScaleLog, ScaleMel} {
+		var buf bytes.Buffer
+		err := RenderSpectrogram(segment, &buf, SpectrogramOptions{
+			STFT:     STFTOptions{FrameSize: 1024, HopSize: 256, Center: true},
+			Width:    200,
+			Height:   100,
+			Scale:    scale,
+			Colormap: ColormapMagma,
+			Axes:     true,
+		})
+		if err != nil {
+			t.Fatalf("failed to render spectrogram: %v", err)
+		}
+
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("failed to decode png: %v", err)
+		}
+		bounds := img.Bounds()
+		if bounds.Dx() != 200+axisLeftMargin || bounds.Dy() != 2*100+channelSeparator+axisBottomMargin {
+			t.Errorf("unexpected image size %v", bounds.Size())
+		}
+	}
+}
+
+func TestRenderSpectrogramPeakRow(t *testing.T) {
+	sampleRate := 16000
+	samples := make([]float64, sampleRate)
+	for i := range samples {
+		samples[i] = 0.5 * math.Sin(2*math.Pi*2000*float64(i)/float64(sampleRate))
+	}
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = RenderSpectrogram(segment, &buf, SpectrogramOptions{
+		STFT:     STFTOptions{FrameSize: 1024, HopSize: 256, Center: true},
+		Width:    50,
+		Height:   80,
+		Colormap: ColormapGrayscale,
+	})
+	if err != nil {
+		t.Fatalf("failed to render spectrogram: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+
+	// 线性刻度下 2000Hz 位于 8000Hz 范围的 1/4 处，该行应最亮
+	brightest, best := 0, uint32(0)
+	for y := 0; y < 80; y++ {
+		r, _, _, _ := img.At(25, y).RGBA()
+		if r > best {
+			brightest, best = y, r
+		}
+	}
+	if expected := 60; brightest < expected-2 || brightest > expected+2 {
+		t.Errorf("expected brightest row near %d, got %d", expected, brightest)
+	}
+}
+
+func TestWaveformPeaks(t *testing.T) {
+	// 立体声，5 帧，每像素 2 帧
+	samples := []float64{0.5, -0.25, -0.5, 0.25, 1.0, 0, -1.0, 0, 0.1, 0.1}
+	segment, err := audio.NewAudioSegment(samples, 8000, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	waveform, err := WaveformPeaks(segment, 2, 8)
+	if err != nil {
+		t.Fatalf("failed to compute waveform: %v", err)
+	}
+	if waveform.Length() != 3 {
+		t.Fatalf("expected 3 pixels, got %d", waveform.Length())
+	}
+
+	expectedMin := [][]int{{-64, -128, 12}, {-32, 0, 12}}
+	expectedMax := [][]int{{64, 127, 12}, {32, 0, 12}}
+	for ch := 0; ch < 2; ch++ {
+		for i := 0; i < 3; i++ {
+			if waveform.Min[ch][i] != expectedMin[ch][i] || waveform.Max[ch][i] != expectedMax[ch][i] {
+				t.Errorf("channel %d pixel %d: expected (%d, %d), got (%d, %d)", ch, i,
+					expectedMin[ch][i], expectedMax[ch][i], waveform.Min[ch][i], waveform.Max[ch][i])
+			}
+		}
+	}
+
+	var jsonBuf bytes.Buffer
+	if err := waveform.WriteJSON(&jsonBuf); err != nil {
+		t.Fatalf("failed to write json: %v", err)
+	}
+	var decoded struct {
+		Version  int   `json:"version"`
+		Channels int   `json:"channels"`
+		Bits     int   `json:"bits"`
+		Length   int   `json:"length"`
+		Data     []int `json:"data"`
+	}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to parse json: %v", err)
+	}
+	if decoded.Version != 2 || decoded.Channels != 2 || decoded.Bits != 8 || decoded.Length != 3 || len(decoded.Data) != 12 {
+		t.Errorf("unexpected json content: %+v", decoded)
+	}
+	if decoded.Data[0] != -64 || decoded.Data[1] != 64 || decoded.Data[2] != -32 || decoded.Data[3] != 32 {
+		t.Errorf("unexpected data order: %v", decoded.Data[:4])
+	}
+
+	var datBuf bytes.Buffer
+	if err := waveform.WriteDat(&datBuf); err != nil {
+		t.Fatalf("failed to write dat: %v", err)
+	}
+	if datBuf.Len() != 24+12 {
+		t.Errorf("expected 36-byte dat file, got %d", datBuf.Len())
+	}
+
+	var pngBuf bytes.Buffer
+	if err := RenderWaveform(waveform, &pngBuf, WaveformImageOptions{Width: 30, Height: 40}); err != nil {
+		t.Fatalf("failed to render waveform: %v", err)
+	}
+	if _, err := png.Decode(&pngBuf); err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+}
+
+func TestWaveformPeaksFromStream(t *testing.T) {
+	samples := make([]float64, 10000)
+	for i := range samples {
+		samples[i] = 0.8 * math.Sin(float64(i)*0.05)
+	}
+	segment, err := audio.NewAudioSegment(samples, 8000, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := stream.FromSegment(segment, &buf, 1024); err != nil {
+		t.Fatalf("failed to write stream: %v", err)
+	}
+	// 缓冲区大小不是声道数的整数倍，验证跨块的声道对齐
+	s, err := stream.NewAudioStream(bytes.NewReader(buf.Bytes()), nil, 8000, 2, 16, 333)
+	if err != nil {
+		t.Fatalf("failed to create stream: %v", err)
+	}
+
+	streamed, err := WaveformPeaksFromStream(s, 256, 16)
+	if err != nil {
+		t.Fatalf("failed to compute waveform from stream: %v", err)
+	}
+	direct, err := WaveformPeaks(segment, 256, 16)
+	if err != nil {
+		t.Fatalf("failed to compute waveform: %v", err)
+	}
+
+	if streamed.Length() != direct.Length() {
+		t.Fatalf("expected %d pixels, got %d", direct.Length(), streamed.Length())
+	}
+	for ch := 0; ch < 2; ch++ {
+		for i := 0; i < direct.Length(); i++ {
+			if d := streamed.Max[ch][i] - direct.Max[ch][i]; d > 2 || d < -2 {
+				t.Errorf("channel %d pixel %d: expected max %d, got %d", ch, i, direct.Max[ch][i], streamed.Max[ch][i])
+			}
+		}
+	}
+}
+
+func TestPitchContour(t *testing.T) {
+	sampleRate := 16000
+	samples := make([]float64, sampleRate)
+	// 前半秒 220Hz 谐波音，后半秒静音
+	for i := 0; i < sampleRate/2; i++ {
+		phase := 2 * math.Pi * 220 * float64(i) / float64(sampleRate)
+		samples[i] = 0.5*math.Sin(phase) + 0.3*math.Sin(2*phase) + 0.2*math.Sin(3*phase)
+	}
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	track, err := PitchContour(segment, 60, 500, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to compute pitch contour: %v", err)
+	}
+
+	for _, frame := range track.Frames {
+		switch {
+		case frame.Time < 400*time.Millisecond:
+			if !frame.Voiced || math.Abs(frame.Frequency-220) > 1 {
+				t.Errorf("frame at %v: expected 220Hz voiced, got %f (voiced=%v)", frame.Time, frame.Frequency, frame.Voiced)
+			}
+		case frame.Time > 600*time.Millisecond:
+			if frame.Voiced {
+				t.Errorf("frame at %v: expected unvoiced, got %f", frame.Time, frame.Frequency)
+			}
+		}
+	}
+
+	stats := track.Stats()
+	if math.Abs(stats.Median-220) > 1 {
+		t.Errorf("expected median pitch 220Hz, got %f", stats.Median)
+	}
+	if stats.VoicedRatio < 0.3 || stats.VoicedRatio > 0.6 {
+		t.Errorf("expected about half of the frames voiced, got %f", stats.VoicedRatio)
+	}
+}
+
+// clickTrack 生成指定速度的打击音轨（衰减的噪声脉冲）
+func clickTrack(sampleRate int, bpm float64, duration time.Duration) ([]float64, []time.Duration) {
+	rng := rand.New(rand.NewSource(5))
+	samples := make([]float64, int(duration.Seconds()*float64(sampleRate)))
+	interval := 60 / bpm
+
+	var clicks []time.Duration
+	for t := 0.25; t < duration.Seconds()-0.1; t += interval {
+		clicks = append(clicks, time.Duration(t*float64(time.Second)))
+		start := int(t * float64(sampleRate))
+		for i := 0; i < sampleRate/20 && start+i < len(samples); i++ {
+			samples[start+i] += 0.8 * math.Exp(-float64(i)/float64(sampleRate)*80) * rng.NormFloat64()
+		}
+	}
+	return samples, clicks
+}
+
+func TestOnsets(t *testing.T) {
+	sampleRate := 22050
+	samples, clicks := clickTrack(sampleRate, 120, 4*time.Second)
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	for _, method := range []OnsetMethod{OnsetSpectralFlux, OnsetEnergy, OnsetHFC} {
+		onsets, err := Onsets(segment, method, 0.5)
+		if err != nil {
+			t.Fatalf("failed to detect onsets: %v", err)
+		}
+		if len(onsets) != len(clicks) {
+			t.Errorf("method %d: expected %d onsets, got %d: %v", method, len(clicks), len(onsets), onsets)
+			continue
+		}
+		for i, onset := range onsets {
+			if diff := onset - clicks[i]; diff < -30*time.Millisecond || diff > 30*time.Millisecond {
+				t.Errorf("method %d onset %d: expected %v, got %v", method, i, clicks[i], onset)
+			}
+		}
+	}
+}
+
+func TestEstimateTempo(t *testing.T) {
+	sampleRate := 22050
+	samples, _ := clickTrack(sampleRate, 128, 10*time.Second)
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	tempo, err := EstimateTempo(segment)
+	if err != nil {
+		t.Fatalf("failed to estimate tempo: %v", err)
+	}
+	if math.Abs(tempo.BPM-128) > 2 {
+		t.Errorf("expected about 128 BPM, got %f", tempo.BPM)
+	}
+	if len(tempo.Beats) < 18 {
+		t.Fatalf("expected at least 18 beats, got %d", len(tempo.Beats))
+	}
+	interval := 60 / 128.0
+	for i := 1; i < len(tempo.Beats); i++ {
+		gap := (tempo.Beats[i] - tempo.Beats[i-1]).Seconds()
+		if math.Abs(gap-interval) > 0.05 {
+			t.Errorf("beat %d: expected interval %f, got %f", i, interval, gap)
+		}
+	}
+}
+
+// sineSegment 生成单声道正弦波片段
+func sineSegment(t *testing.T, freq float64, sampleRate int, duration time.Duration) *audio.AudioSegment {
+	t.Helper()
+	samples := make([]float64, int(duration.Seconds()*float64(sampleRate)))
+	for i := range samples {
+		samples[i] = 0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+	}
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	return segment
+}
+
+func TestMelSpectrogram(t *testing.T) {
+	sampleRate := 22050
+	segment := sineSegment(t, 1000, sampleRate, time.Second)
+
+	options := DefaultMelOptions()
+	mel, err := MelSpectrogram(segment, options)
+	if err != nil {
+		t.Fatalf("failed to compute mel spectrogram: %v", err)
+	}
+	// librosa: 1 + len/hop 帧
+	if expected := 1 + sampleRate/512; len(mel) != expected {
+		t.Fatalf("expected %d frames, got %d", expected, len(mel))
+	}
+	if len(mel[0]) != 128 {
+		t.Fatalf("expected 128 mel bands, got %d", len(mel[0]))
+	}
+
+	// 能量最大的梅尔带中心频率应接近 1000Hz
+	frame := mel[len(mel)/2]
+	peak := 0
+	for m := range frame {
+		if frame[m] > frame[peak] {
+			peak = m
+		}
+	}
+	maxMel := HzToMel(float64(sampleRate)/2, false)
+	center := MelToHz(maxMel*float64(peak+1)/129, false)
+	if math.Abs(center-1000) > 60 {
+		t.Errorf("expected peak mel band near 1000Hz, got %f", center)
+	}
+
+	// Slaney 归一化：每个滤波器在 Hz 上的面积约为 1
+	filters := MelFilterBank(sampleRate, 2048, 40, 0, 8000, false)
+	binWidth := float64(sampleRate) / 2048
+	for m, filter := range filters[5:] {
+		var area float64
+		for _, w := range filter {
+			area += w * binWidth
+		}
+		if math.Abs(area-1) > 0.1 {
+			t.Errorf("filter %d: expected unit area, got %f", m+5, area)
+		}
+	}
+}
+
+func TestMFCC(t *testing.T) {
+	segment := sineSegment(t, 440, 22050, 500*time.Millisecond)
+
+	mfcc, err := MFCC(segment, 13, DefaultMelOptions())
+	if err != nil {
+		t.Fatalf("failed to compute MFCC: %v", err)
+	}
+	for i, frame := range mfcc {
+		if len(frame) != 13 {
+			t.Fatalf("frame %d: expected 13 coefficients, got %d", i, len(frame))
+		}
+	}
+
+	// 线性增长特征的一阶差分在内部应等于斜率
+	ramp := make([][]float64, 20)
+	for i := range ramp {
+		ramp[i] = []float64{2 * float64(i)}
+	}
+	delta := Delta(ramp, 9)
+	for i := 4; i < 16; i++ {
+		if math.Abs(delta[i][0]-2) > 1e-9 {
+			t.Errorf("frame %d: expected delta 2, got %f", i, delta[i][0])
+		}
+	}
+}
+
+func TestChroma(t *testing.T) {
+	tests := []struct {
+		name  string
+		freq  float64
+		pitch int
+	}{
+		{"A4", 440, 9},
+		{"C4", 261.63, 0},
+		{"E5", 659.26, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segment := sineSegment(t, tt.freq, 22050, 500*time.Millisecond)
+			chroma, err := Chroma(segment, DefaultSTFTOptions())
+			if err != nil {
+				t.Fatalf("failed to compute chroma: %v", err)
+			}
+			frame := chroma[len(chroma)/2]
+			if len(frame) != 12 {
+				t.Fatalf("expected 12 chroma bins, got %d", len(frame))
+			}
+			if frame[tt.pitch] != 1 {
+				t.Errorf("expected chroma %d to be the maximum, got %v", tt.pitch, frame)
+			}
+		})
+	}
+}
+
+func TestSpectralFeatures(t *testing.T) {
+	sampleRate := 22050
+	sine := sineSegment(t, 1000, sampleRate, time.Second)
+
+	features, err := ExtractSpectralFeatures(sine, DefaultSTFTOptions())
+	if err != nil {
+		t.Fatalf("failed to extract spectral features: %v", err)
+	}
+	mid := len(features.Centroid) / 2
+	if math.Abs(features.Centroid[mid]-1000) > 50 {
+		t.Errorf("expected centroid near 1000Hz, got %f", features.Centroid[mid])
+	}
+	if math.Abs(features.Rolloff[mid]-1000) > 50 {
+		t.Errorf("expected rolloff near 1000Hz, got %f", features.Rolloff[mid])
+	}
+	if features.Flatness[mid] > 0.01 {
+		t.Errorf("expected low flatness for a sine, got %f", features.Flatness[mid])
+	}
+	if expected := 2 * 1000 / float64(sampleRate); math.Abs(features.ZeroCrossingRate[mid]-expected) > 0.002 {
+		t.Errorf("expected zero crossing rate %f, got %f", expected, features.ZeroCrossingRate[mid])
+	}
+	if len(features.ZeroCrossingRate) != len(features.Centroid) {
+		t.Errorf("expected %d zero crossing frames, got %d", len(features.Centroid), len(features.ZeroCrossingRate))
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float64, sampleRate)
+	for i := range samples {
+		samples[i] = rng.Float64()*2 - 1
+	}
+	noise, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	noisy, err := ExtractSpectralFeatures(noise, DefaultSTFTOptions())
+	if err != nil {
+		t.Fatalf("failed to extract spectral features: %v", err)
+	}
+	if noisy.Flatness[mid] < 0.3 {
+		t.Errorf("expected high flatness for white noise, got %f", noisy.Flatness[mid])
+	}
+	if noisy.Bandwidth[mid] <= features.Bandwidth[mid] {
+		t.Errorf("expected noise bandwidth %f to exceed sine bandwidth %f", noisy.Bandwidth[mid], features.Bandwidth[mid])
+	}
+}
+
+// speechLikeSignal 在白噪声上叠加若干段调幅谐波音（模拟浊音），返回样本和语音区间
+func speechLikeSignal(sampleRate int) ([]float64, []SpeechRegion) {
+	rng := rand.New(rand.NewSource(3))
+	samples := make([]float64, 4*sampleRate)
+	for i := range samples {
+		samples[i] = 0.003 * (rng.Float64()*2 - 1)
+	}
+
+	regions := []SpeechRegion{
+		{Start: time.Second, End: 2 * time.Second},
+		{Start: 3 * time.Second, End: 3500 * time.Millisecond},
+	}
+	for _, region := range regions {
+		start := int(region.Start.Seconds() * float64(sampleRate))
+		end := int(region.End.Seconds() * float64(sampleRate))
+		for i := start; i < end; i++ {
+			t := float64(i-start) / float64(sampleRate)
+			envelope := 0.6 + 0.4*math.Sin(2*math.Pi*4*t)
+			var voice float64
+			for k := 1; k <= 15; k++ {
+				voice += math.Sin(2*math.Pi*150*float64(k)*t) / float64(k)
+			}
+			samples[i] += 0.2 * envelope * voice
+		}
+	}
+	return samples, regions
+}
+
+func TestDetectSpeech(t *testing.T) {
+	sampleRate := 16000
+	samples, expected := speechLikeSignal(sampleRate)
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	for _, mode := range []VADMode{VADQuality, VADLowBitrate, VADAggressive, VADVeryAggressive} {
+		options := DefaultVADOptions()
+		options.Mode = mode
+		regions, err := DetectSpeech(segment, options)
+		if err != nil {
+			t.Fatalf("mode %d: failed to detect speech: %v", mode, err)
+		}
+		if len(regions) != len(expected) {
+			t.Fatalf("mode %d: expected %d regions, got %v", mode, len(expected), regions)
+		}
+		tolerance := options.Padding + options.Hangover + options.FrameDuration
+		for i, region := range regions {
+			if diff := region.Start - expected[i].Start; diff > 0 || -diff > tolerance {
+				t.Errorf("mode %d: region %d: expected start near %v, got %v", mode, i, expected[i].Start, region.Start)
+			}
+			if diff := region.End - expected[i].End; diff < 0 || diff > tolerance {
+				t.Errorf("mode %d: region %d: expected end near %v, got %v", mode, i, expected[i].End, region.End)
+			}
+		}
+	}
+}
+
+func TestVoiceActivityFromStream(t *testing.T) {
+	sampleRate := 16000
+	mono, expected := speechLikeSignal(sampleRate)
+	// 立体声输入，缓冲区大小故意不对齐帧长和声道数
+	samples := make([]float64, 2*len(mono))
+	for i, s := range mono {
+		samples[2*i] = s
+		samples[2*i+1] = s
+	}
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := stream.FromSegment(segment, &buf, 1024); err != nil {
+		t.Fatalf("failed to write stream: %v", err)
+	}
+	s, err := stream.NewAudioStream(bytes.NewReader(buf.Bytes()), nil, sampleRate, 2, 16, 1001)
+	if err != nil {
+		t.Fatalf("failed to create stream: %v", err)
+	}
+
+	options := DefaultVADOptions()
+	frames, err := VoiceActivityFromStream(s, options)
+	if err != nil {
+		t.Fatalf("failed to run VAD on stream: %v", err)
+	}
+	if expectedFrames := len(mono) / (sampleRate * 30 / 1000); len(frames) != expectedFrames {
+		t.Fatalf("expected %d frames, got %d", expectedFrames, len(frames))
+	}
+
+	regions := SpeechRegions(frames, options)
+	if len(regions) != len(expected) {
+		t.Fatalf("expected %d regions, got %v", len(expected), regions)
+	}
+}
+
+// toneSequence 生成随机音高序列（每 100ms 换一组音），用作指纹测试的参考音频
+func toneSequence(t *testing.T, seed int64, sampleRate int, duration time.Duration) *audio.AudioSegment {
+	t.Helper()
+	rng := rand.New(rand.NewSource(seed))
+	samples := make([]float64, int(duration.Seconds()*float64(sampleRate)))
+	step := sampleRate / 10
+	var freqs [3]float64
+	for i := range samples {
+		if i%step == 0 {
+			for j := range freqs {
+				freqs[j] = 200 + rng.Float64()*3000
+			}
+		}
+		for _, f := range freqs {
+			samples[i] += 0.2 * math.Sin(2*math.Pi*f*float64(i)/float64(sampleRate))
+		}
+	}
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	return segment
+}
+
+func TestFingerprintIndex(t *testing.T) {
+	sampleRate := 11025
+	index := NewFingerprintIndex()
+	references := make([]*audio.AudioSegment, 3)
+	for i := range references {
+		references[i] = toneSequence(t, int64(i+10), sampleRate, 10*time.Second)
+		if err := index.Add(string(rune('a'+i)), references[i]); err != nil {
+			t.Fatalf("failed to add reference: %v", err)
+		}
+	}
+	if index.Len() != 3 {
+		t.Fatalf("expected 3 references, got %d", index.Len())
+	}
+
+	// 从第二个参考音频截取 3 秒并加入噪声
+	clip, err := references[1].Slice(3200*time.Millisecond, 6200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to slice reference: %v", err)
+	}
+	rng := rand.New(rand.NewSource(5))
+	noisy := clip.Samples()
+	for i := range noisy {
+		noisy[i] += 0.05 * (rng.Float64()*2 - 1)
+	}
+	query, err := audio.NewAudioSegment(noisy, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	match, err := index.Match(query)
+	if err != nil {
+		t.Fatalf("failed to match query: %v", err)
+	}
+	if match.ID != "b" {
+		t.Errorf("expected reference b, got %s", match.ID)
+	}
+	if diff := match.Offset - 3200*time.Millisecond; diff < -50*time.Millisecond || diff > 50*time.Millisecond {
+		t.Errorf("expected offset near 3.2s, got %v", match.Offset)
+	}
+	if match.Confidence < 0.2 {
+		t.Errorf("expected confidence above 0.2, got %f", match.Confidence)
+	}
+
+	// 与索引无关的音频不应匹配
+	unrelated := toneSequence(t, 99, sampleRate, 3*time.Second)
+	if match, err := index.Match(unrelated); err != ErrNoMatch {
+		t.Errorf("expected no match, got %+v (err=%v)", match, err)
+	}
+}
+
+func TestFingerprintOffsetAt48k(t *testing.T) {
+	// 48kHz 下实际帧移为 1115 个样本（23.229ms），与名义帧移 23.220ms 的差异会随偏移累积
+	sampleRate := 48000
+	excerpt := toneSequence(t, 21, sampleRate, 12*time.Second)
+	fingerprint, err := ComputeFingerprint(excerpt)
+	if err != nil {
+		t.Fatalf("failed to compute fingerprint: %v", err)
+	}
+	if fingerprint.HopSize != 1115 || fingerprint.SampleRate != sampleRate {
+		t.Fatalf("expected hop 1115 at 48kHz, got %d at %d", fingerprint.HopSize, fingerprint.SampleRate)
+	}
+
+	// 将参考指纹整体后移约 3 分钟，模拟位于长参考音频中部的片段
+	shift := 7750
+	for i := range fingerprint.Landmarks {
+		fingerprint.Landmarks[i].Frame += shift
+	}
+	fingerprint.Frames += shift
+	index := NewFingerprintIndex()
+	index.AddFingerprint("long", fingerprint)
+
+	query, err := excerpt.Slice(5*time.Second, 9*time.Second)
+	if err != nil {
+		t.Fatalf("failed to slice reference: %v", err)
+	}
+	match, err := index.Match(query)
+	if err != nil {
+		t.Fatalf("failed to match query: %v", err)
+	}
+	expected := time.Duration(float64(shift*1115)/float64(sampleRate)*float64(time.Second)) + 5*time.Second
+	if diff := match.Offset - expected; diff < -30*time.Millisecond || diff > 30*time.Millisecond {
+		t.Errorf("expected offset near %v, got %v", expected, match.Offset)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	sampleRate := 8000
+	rng := rand.New(rand.NewSource(7))
+	ref := make([]float64, 2*sampleRate)
+	for i := range ref {
+		ref[i] = rng.Float64()*2 - 1
+	}
+	reference, err := audio.NewAudioSegment(ref, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		lag  int
+	}{
+		{"late take", -3000},
+		{"early take", 1234},
+		{"in sync", 0},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []CorrelationMethod{CorrelationPlain, CorrelationPHAT} {
+			t.Run(tt.name, func(t *testing.T) {
+				// target[n] = reference[n+lag] 加少量噪声
+				tgt := make([]float64, len(ref))
+				for i := range tgt {
+					if j := i + tt.lag; j >= 0 && j < len(ref) {
+						tgt[i] = ref[j]
+					}
+					tgt[i] += 0.1 * (rng.Float64()*2 - 1)
+				}
+				target, err := audio.NewAudioSegment(tgt, sampleRate, 1, 16)
+				if err != nil {
+					t.Fatalf("failed to create audio segment: %v", err)
+				}
+
+				aligned, alignment, err := AlignTo(reference, target.WithTags(map[string]string{"title": "Take 2"}), time.Second, method)
+				if err != nil {
+					t.Fatalf("failed to align: %v", err)
+				}
+				if alignment.Samples != tt.lag {
+					t.Errorf("method %d: expected lag %d, got %d", method, tt.lag, alignment.Samples)
+				}
+				if alignment.Strength < 0.3 {
+					t.Errorf("method %d: expected strong correlation, got %f", method, alignment.Strength)
+				}
+				if aligned.Duration() != reference.Duration() {
+					t.Errorf("expected duration %v, got %v", reference.Duration(), aligned.Duration())
+				}
+				if aligned.Tag("title") != "Take 2" {
+					t.Errorf("expected the take's tags to be kept, got %v", aligned.Tags())
+				}
+				out := aligned.Samples()
+				for i := 4000; i < 5000; i++ {
+					if math.Abs(out[i]-ref[i]) > 0.11 {
+						t.Fatalf("sample %d: expected %f, got %f", i, ref[i], out[i])
+					}
+				}
+			})
+		}
+	}
+
+	// 搜索范围不足时不应返回范围外的偏移
+	far := make([]float64, len(ref))
+	copy(far[4000:], ref)
+	delayed, err := audio.NewAudioSegment(far, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	alignment, err := Align(delayed, reference, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to align: %v", err)
+	}
+	if alignment.Samples < -800 || alignment.Samples > 800 {
+		t.Errorf("expected lag within ±800 samples, got %d", alignment.Samples)
+	}
+}
+
+func TestAlignFiveChannels(t *testing.T) {
+	// 5 声道没有默认布局（Layout 为 0），平移和对齐不应因此失败
+	sampleRate := 8000
+	rng := rand.New(rand.NewSource(11))
+	frames := sampleRate
+	ref := make([]float64, frames*5)
+	for i := range ref {
+		ref[i] = rng.Float64()*2 - 1
+	}
+	reference, err := audio.NewAudioSegment(ref, sampleRate, 5, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	shifted, err := Shift(reference, 100, frames)
+	if err != nil {
+		t.Fatalf("failed to shift: %v", err)
+	}
+	if shifted.Channels() != 5 || shifted.Layout() != reference.Layout() {
+		t.Errorf("expected 5 channels with layout %v, got %d channels with layout %v",
+			reference.Layout(), shifted.Channels(), shifted.Layout())
+	}
+
+	aligned, alignment, err := AlignTo(reference, shifted, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to align: %v", err)
+	}
+	if alignment.Samples != -100 {
+		t.Errorf("expected lag -100, got %d", alignment.Samples)
+	}
+	if aligned.Channels() != 5 {
+		t.Errorf("expected 5 channels, got %d", aligned.Channels())
+	}
+}
+
+func TestDTWAlign(t *testing.T) {
+	sampleRate := 16000
+	// 导轨与录音包含同样的三个音，但各音的时长不同
+	build := func(lengths []int) []float64 {
+		var samples []float64
+		for i, freq := range []float64{300, 900, 1800} {
+			for j := 0; j < lengths[i]; j++ {
+				samples = append(samples, 0.5*math.Sin(2*math.Pi*freq*float64(j)/float64(sampleRate)))
+			}
+		}
+		return samples
+	}
+	guide, err := audio.NewAudioSegment(build([]int{8000, 8000, 8000}), sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	take, err := audio.NewAudioSegment(build([]int{4000, 12000, 6000}), sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	path, err := DTWAlign(guide, take)
+	if err != nil {
+		t.Fatalf("failed to align: %v", err)
+	}
+	first, last := path.Points[0], path.Points[len(path.Points)-1]
+	if first.Guide != 0 || first.Take != 0 || last.Guide != path.GuideFrames-1 || last.Take != path.TakeFrames-1 {
+		t.Fatalf("expected path from (0,0) to the last frames, got %v to %v", first, last)
+	}
+
+	// 音与音之间的边界应被映射到录音中对应的边界
+	boundaries := []struct {
+		guide, take time.Duration
+	}{
+		{500 * time.Millisecond, 250 * time.Millisecond},
+		{time.Second, time.Second},
+	}
+	for _, b := range boundaries {
+		got := path.TakeTime(b.guide)
+		if diff := got - b.take; diff < -40*time.Millisecond || diff > 40*time.Millisecond {
+			t.Errorf("guide %v: expected take time near %v, got %v", b.guide, b.take, got)
+		}
+	}
+
+	// 22050Hz 时帧移为 220 个样本（9.977ms），帧时长应按实际帧移计算；
+	// 导轨与录音采样率不同时，同一内容应映射到同一时间点
+	tone := func(sampleRate int) *audio.AudioSegment {
+		samples := make([]float64, 20*sampleRate)
+		for i := range samples {
+			freq := 300.0
+			if i/sampleRate%2 == 1 {
+				freq = 1200
+			}
+			samples[i] = 0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+		}
+		segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+		if err != nil {
+			t.Fatalf("failed to create audio segment: %v", err)
+		}
+		return segment
+	}
+	path, err = DTWAlign(tone(22050), tone(16000))
+	if err != nil {
+		t.Fatalf("failed to align: %v", err)
+	}
+	if expected := 220 * time.Second / 22050; path.FrameDuration != expected {
+		t.Errorf("expected guide frame duration %v, got %v", expected, path.FrameDuration)
+	}
+	if path.TakeFrameDuration != 10*time.Millisecond {
+		t.Errorf("expected take frame duration 10ms, got %v", path.TakeFrameDuration)
+	}
+	if got := path.TakeTime(19 * time.Second); got < 18960*time.Millisecond || got > 19040*time.Millisecond {
+		t.Errorf("expected take time near 19s, got %v", got)
+	}
+}
+
+func TestDTWAlignShortGuide(t *testing.T) {
+	sampleRate := 8000
+	sine := func(length int) *audio.AudioSegment {
+		samples := make([]float64, length)
+		for i := range samples {
+			samples[i] = 0.5 * math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate))
+		}
+		segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+		if err != nil {
+			t.Fatalf("failed to create audio segment: %v", err)
+		}
+		return segment
+	}
+
+	// 导轨只有 1~2 帧，录音远长于导轨时带宽仍需覆盖到终点
+	for _, guideLength := range []int{40, 100} {
+		for _, takeLength := range []int{800, 8000, 40000} {
+			path, err := DTWAlign(sine(guideLength), sine(takeLength))
+			if err != nil {
+				t.Fatalf("guide %d, take %d: failed to align: %v", guideLength, takeLength, err)
+			}
+			first, last := path.Points[0], path.Points[len(path.Points)-1]
+			if first.Guide != 0 || first.Take != 0 || last.Guide != path.GuideFrames-1 || last.Take != path.TakeFrames-1 {
+				t.Errorf("guide %d, take %d: expected path from (0,0) to the last frames, got %v to %v",
+					guideLength, takeLength, first, last)
+			}
+		}
+	}
+}
+
+// chordProgression 依次生成每秒一个三和弦（各音为带两个泛音的音），音高以 MIDI 编号给出
+func chordProgression(t *testing.T, sampleRate int, chords [][]int) *audio.AudioSegment {
+	t.Helper()
+	samples := make([]float64, len(chords)*sampleRate)
+	for n, notes := range chords {
+		for _, note := range notes {
+			freq := 440 * math.Pow(2, float64(note-69)/12)
+			for i := 0; i < sampleRate; i++ {
+				phase := 2 * math.Pi * freq * float64(i) / float64(sampleRate)
+				samples[n*sampleRate+i] += 0.15*math.Sin(phase) + 0.05*math.Sin(2*phase) + 0.02*math.Sin(3*phase)
+			}
+		}
+	}
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	return segment
+}
+
+func TestEstimateKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		chords [][]int
+		tonic  PitchClass
+		mode   KeyMode
+	}{
+		// C - F - G - C
+		{"C major", [][]int{{60, 64, 67}, {65, 69, 72}, {67, 71, 74}, {60, 64, 67}}, 0, Major},
+		// Am - Dm - E - Am
+		{"A minor", [][]int{{57, 60, 64}, {62, 65, 69}, {64, 68, 71}, {57, 60, 64}}, 9, Minor},
+		// D - G - A - D
+		{"D major", [][]int{{62, 66, 69}, {67, 71, 74}, {69, 73, 76}, {62, 66, 69}}, 2, Major},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := EstimateKey(chordProgression(t, 22050, tt.chords))
+			if err != nil {
+				t.Fatalf("failed to estimate key: %v", err)
+			}
+			if key.Tonic != tt.tonic || key.Mode != tt.mode {
+				t.Errorf("expected %s, got %s (candidates %v)", tt.name, key, key.Candidates[:3])
+			}
+			if key.Confidence <= 0 {
+				t.Errorf("expected positive confidence, got %f", key.Confidence)
+			}
+			if len(key.Candidates) != 24 {
+				t.Errorf("expected 24 candidates, got %d", len(key.Candidates))
+			}
+		})
+	}
+
+	// 静音没有调性，应返回错误而不是置信度为 0 的 C 大调
+	silence, err := audio.NewAudioSegment(make([]float64, 22050), 22050, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	if key, err := EstimateKey(silence); err == nil {
+		t.Errorf("expected error for silence, got %s", key)
+	}
+}
+
+func TestRecognizeChords(t *testing.T) {
+	sampleRate := 22050
+	// C - Am - F - G，最后一秒静音
+	segment := chordProgression(t, sampleRate, [][]int{{60, 64, 67}, {57, 60, 64}, {53, 57, 60}, {55, 59, 62}, {}})
+	expected := []string{"C", "Am", "F", "G", "N"}
+
+	frames, err := RecognizeChords(segment)
+	if err != nil {
+		t.Fatalf("failed to recognize chords: %v", err)
+	}
+	for _, frame := range frames {
+		second := int(frame.Time / time.Second)
+		offset := frame.Time % time.Second
+		// 跳过和弦交界处受平滑影响的帧
+		if second >= len(expected) || offset < 300*time.Millisecond || offset > 700*time.Millisecond {
+			continue
+		}
+		if got := frame.Chord.String(); got != expected[second] {
+			t.Errorf("frame at %v: expected %s, got %s (score %f)", frame.Time, expected[second], got, frame.Score)
+		}
+	}
+}
+
+func TestAnalyzeStereo(t *testing.T) {
+	sampleRate := 8000
+	rng := rand.New(rand.NewSource(11))
+	left := make([]float64, sampleRate)
+	right := make([]float64, sampleRate)
+	for i := range left {
+		left[i] = 0.5 * (rng.Float64()*2 - 1)
+		right[i] = 0.5 * (rng.Float64()*2 - 1)
+	}
+
+	tests := []struct {
+		name        string
+		right       func(i int) float64
+		correlation float64
+		monoLoss    float64
+		balance     float64
+	}{
+		{"mono", func(i int) float64 { return left[i] }, 1, 0, 0},
+		{"uncorrelated", func(i int) float64 { return right[i] }, 0, 3.01, 0},
+		{"half level", func(i int) float64 { return left[i] / 2 }, 1, 0.51, 6.02},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := make([]float64, 2*len(left))
+			for i := range left {
+				samples[2*i] = left[i]
+				samples[2*i+1] = tt.right(i)
+			}
+			segment, err := audio.NewAudioSegment(samples, sampleRate, 2, 16)
+			if err != nil {
+				t.Fatalf("failed to create audio segment: %v", err)
+			}
+
+			report, err := AnalyzeStereo(segment, 100*time.Millisecond)
+			if err != nil {
+				t.Fatalf("failed to analyze stereo: %v", err)
+			}
+			if len(report.Frames) != 10 {
+				t.Errorf("expected 10 frames, got %d", len(report.Frames))
+			}
+			if math.Abs(report.Correlation-tt.correlation) > 0.05 {
+				t.Errorf("expected correlation %f, got %f", tt.correlation, report.Correlation)
+			}
+			if math.Abs(report.MonoLoss-tt.monoLoss) > 0.2 {
+				t.Errorf("expected mono loss %f dB, got %f", tt.monoLoss, report.MonoLoss)
+			}
+			if math.Abs(report.Balance-tt.balance) > 0.2 {
+				t.Errorf("expected balance %f dB, got %f", tt.balance, report.Balance)
+			}
+		})
+	}
+
+	// 反相：相关系数为 -1，合并后几乎完全抵消
+	samples := make([]float64, 2*len(left))
+	for i := range left {
+		samples[2*i] = left[i]
+		samples[2*i+1] = -left[i]
+	}
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	report, err := AnalyzeStereo(segment, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to analyze stereo: %v", err)
+	}
+	if report.MinCorrelation > -0.99 || report.MonoLoss < 60 {
+		t.Errorf("expected out-of-phase result, got correlation %f and mono loss %f", report.MinCorrelation, report.MonoLoss)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderGoniometer(segment, &buf, GoniometerOptions{Size: 128, Meter: true}); err != nil {
+		t.Fatalf("failed to render goniometer: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 128 || b.Dy() != 128+goniometerMeterHeight {
+		t.Errorf("expected 128x%d image, got %dx%d", 128+goniometerMeterHeight, b.Dx(), b.Dy())
+	}
+	// 反相信号应全部落在水平线上：中心行有点，中心列上下方没有
+	background := color.RGBA{A: 255}
+	if img.At(40, 64) == background {
+		t.Errorf("expected points on the horizontal axis")
+	}
+	if img.At(64, 10) != background {
+		t.Errorf("expected no points above the center, got %v", img.At(63, 10))
+	}
+
+	// 满幅单声道方波（L=R=±1）应落在竖线的上下两端
+	for i := range left {
+		samples[2*i] = 1
+		if math.Sin(2*math.Pi*100*float64(i)/float64(sampleRate)) < 0 {
+			samples[2*i] = -1
+		}
+		samples[2*i+1] = samples[2*i]
+	}
+	mono, err := audio.NewAudioSegment(samples, sampleRate, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	buf.Reset()
+	if err := RenderGoniometer(mono, &buf, GoniometerOptions{Size: 128}); err != nil {
+		t.Fatalf("failed to render goniometer: %v", err)
+	}
+	if img, err = png.Decode(&buf); err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	for _, y := range []int{0, 127} {
+		if img.At(64, y) == background {
+			t.Errorf("expected full-scale mono point at row %d", y)
+		}
+	}
+	if img.At(20, 64) != background {
+		t.Errorf("expected no points off the vertical axis, got %v", img.At(20, 64))
+	}
+}
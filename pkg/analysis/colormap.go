@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"image/color"
+	"math"
+)
+
+// Colormap 颜色映射
+type Colormap int
+
+const (
+	// ColormapViridis matplotlib viridis 配色
+	ColormapViridis Colormap = iota
+	// ColormapMagma matplotlib magma 配色
+	ColormapMagma
+	// ColormapGrayscale 灰度
+	ColormapGrayscale
+)
+
+// 等间距的配色锚点，中间值线性插值
+var colormapAnchors = map[Colormap][][3]uint8{
+	ColormapViridis: {
+		{68, 1, 84}, {71, 44, 122}, {59, 81, 139}, {44, 113, 142}, {33, 144, 141},
+		{39, 173, 129}, {92, 200, 99}, {170, 220, 50}, {253, 231, 37},
+	},
+	ColormapMagma: {
+		{0, 0, 4}, {28, 16, 68}, {79, 18, 123}, {129, 37, 129}, {181, 54, 122},
+		{229, 80, 100}, {251, 135, 97}, {254, 194, 135}, {252, 253, 191},
+	},
+	ColormapGrayscale: {
+		{0, 0, 0}, {255, 255, 255},
+	},
+}
+
+// Color 返回 [0, 1] 范围内的值对应的颜色
+func (c Colormap) Color(value float64) color.RGBA {
+	anchors, ok := colormapAnchors[c]
+	if !ok {
+		anchors = colormapAnchors[ColormapViridis]
+	}
+
+	value = math.Max(0, math.Min(1, value))
+	pos := value * float64(len(anchors)-1)
+	i := int(pos)
+	if i >= len(anchors)-1 {
+		i = len(anchors) - 2
+	}
+	frac := pos - float64(i)
+
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*frac))
+	}
+	return color.RGBA{
+		R: lerp(anchors[i][0], anchors[i+1][0]),
+		G: lerp(anchors[i][1], anchors[i+1][1]),
+		B: lerp(anchors[i][2], anchors[i+1][2]),
+		A: 255,
+	}
+}
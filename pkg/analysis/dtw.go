@@ -0,0 +1,261 @@
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DTW 特征：10ms 帧移、约 40ms 帧长的 MFCC（去掉 c0，对响度不敏感），梅尔上限统一为 8kHz
+	dtwHop       = 10 * time.Millisecond
+	dtwFrameTime = 0.04
+	dtwMFCC      = 13
+	dtwMaxFreq   = 8000.0
+	// Sakoe-Chiba 带宽：对角线两侧各放宽较长序列的 1/4，另加两段长度之比以覆盖对角线斜率
+	dtwBandRatio = 0.25
+)
+
+// WarpPoint 规整路径上的一点（两段音频的帧索引）
+type WarpPoint struct {
+	Guide int
+	Take  int
+}
+
+// WarpPath 动态时间规整结果，路径从 (0,0) 单调延伸到两段音频的最后一帧
+type WarpPath struct {
+	Points            []WarpPoint
+	GuideFrames       int
+	TakeFrames        int
+	FrameDuration     time.Duration // 导轨一帧的实际时长（帧移样本数 / 采样率，约 10ms）
+	TakeFrameDuration time.Duration // 录音一帧的实际时长，采样率不同时与导轨不同
+	Cost              float64       // 路径上的平均帧距离
+}
+
+// Mapping 返回导轨每一帧对应的录音帧位置（同一导轨帧对应多帧时取平均）
+func (p *WarpPath) Mapping() []float64 {
+	sums := make([]float64, p.GuideFrames)
+	counts := make([]int, p.GuideFrames)
+	for _, point := range p.Points {
+		sums[point.Guide] += float64(point.Take)
+		counts[point.Guide]++
+	}
+	for i := range sums {
+		if counts[i] > 0 {
+			sums[i] /= float64(counts[i])
+		}
+	}
+	return sums
+}
+
+// TakeTime 返回导轨时间点对应的录音时间点（帧间线性插值）
+func (p *WarpPath) TakeTime(guide time.Duration) time.Duration {
+	mapping := p.Mapping()
+	position := float64(guide) / float64(p.FrameDuration)
+	return time.Duration(interpolate(mapping, position) * float64(p.TakeFrameDuration))
+}
+
+// interpolate 在等间隔序列上线性插值，超出范围时按端点斜率外推
+func interpolate(values []float64, position float64) float64 {
+	n := len(values)
+	if n == 1 {
+		return values[0] + position
+	}
+	i := int(math.Floor(position))
+	if i < 0 {
+		i = 0
+	}
+	if i > n-2 {
+		i = n - 2
+	}
+	frac := position - float64(i)
+	return values[i] + frac*(values[i+1]-values[i])
+}
+
+// DTWAlign 在 MFCC 特征上对导轨和录音做动态时间规整，返回规整路径；
+// 两段音频的采样率可以不同，多声道时先混合为单声道
+func DTWAlign(guide, take *audio.AudioSegment) (*WarpPath, error) {
+	guideFeatures, guideFrame, err := dtwFeatures(guide)
+	if err != nil {
+		return nil, err
+	}
+	takeFeatures, takeFrame, err := dtwFeatures(take)
+	if err != nil {
+		return nil, err
+	}
+
+	points, cost, err := dtw(guideFeatures, takeFeatures)
+	if err != nil {
+		return nil, err
+	}
+	return &WarpPath{
+		Points:            points,
+		GuideFrames:       len(guideFeatures),
+		TakeFrames:        len(takeFeatures),
+		FrameDuration:     guideFrame,
+		TakeFrameDuration: takeFrame,
+		Cost:              cost,
+	}, nil
+}
+
+// dtwFeatures 计算去掉 c0 并做均值方差归一化的 MFCC，同时返回按实际帧移计算的帧时长
+func dtwFeatures(segment *audio.AudioSegment) ([][]float64, time.Duration, error) {
+	if segment == nil {
+		return nil, 0, errors.New("segment cannot be nil")
+	}
+	sampleRate := segment.SampleRate()
+	options := MelOptions{
+		STFT: STFTOptions{
+			FrameSize: NextPowerOfTwo(int(dtwFrameTime * float64(sampleRate))),
+			HopSize:   int(dtwHop.Seconds() * float64(sampleRate)),
+			Window:    Hann,
+			Center:    true,
+		},
+		NMels: 40,
+		FMax:  math.Min(dtwMaxFreq, float64(sampleRate)/2),
+	}
+	if options.STFT.HopSize == 0 {
+		return nil, 0, errors.New("sample rate too low for DTW")
+	}
+
+	mfcc, err := MFCC(segment, dtwMFCC, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	features := make([][]float64, len(mfcc))
+	for i, frame := range mfcc {
+		features[i] = frame[1:]
+	}
+	normalizeFeatures(features)
+	return features, frameDuration(options.STFT.HopSize, float64(sampleRate)), nil
+}
+
+// normalizeFeatures 对每一维做零均值、单位方差归一化，消除话筒和电平差异
+func normalizeFeatures(features [][]float64) {
+	if len(features) == 0 {
+		return
+	}
+	for d := range features[0] {
+		var mean, variance float64
+		for _, frame := range features {
+			mean += frame[d]
+		}
+		mean /= float64(len(features))
+		for _, frame := range features {
+			variance += (frame[d] - mean) * (frame[d] - mean)
+		}
+		std := math.Sqrt(variance / float64(len(features)))
+		if std < 1e-9 {
+			std = 1
+		}
+		for _, frame := range features {
+			frame[d] = (frame[d] - mean) / std
+		}
+	}
+}
+
+// dtw 在 Sakoe-Chiba 带内计算累计代价并回溯最优路径
+func dtw(a, b [][]float64) ([]WarpPoint, float64, error) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil, 0, errors.New("cannot align empty feature sequences")
+	}
+	// 一段远长于另一段时，相邻两行的对角线中心相差 max/min 列，带宽至少要覆盖这一斜率
+	slope := (max(n, m) + min(n, m) - 1) / min(n, m)
+	radius := slope + int(dtwBandRatio*float64(max(n, m))) + 1
+
+	// 第 i 行只保存 [lo[i], hi[i]] 范围内的列
+	lo := make([]int, n)
+	hi := make([]int, n)
+	cost := make([][]float64, n)
+	steps := make([][]uint8, n)
+	for i := 0; i < n; i++ {
+		center := 0
+		if n > 1 {
+			center = int(math.Round(float64(i) * float64(m-1) / float64(n-1)))
+		}
+		lo[i], hi[i] = max(0, center-radius), min(m-1, center+radius)
+		// 带内必须包含起点 (0,0) 和终点 (n-1,m-1)
+		if i == 0 {
+			lo[i] = 0
+		}
+		if i == n-1 {
+			hi[i] = m - 1
+		}
+		cost[i] = make([]float64, hi[i]-lo[i]+1)
+		steps[i] = make([]uint8, hi[i]-lo[i]+1)
+	}
+
+	at := func(i, j int) float64 {
+		if i < 0 || j < lo[i] || j > hi[i] {
+			return math.Inf(1)
+		}
+		return cost[i][j-lo[i]]
+	}
+
+	const (
+		stepDiagonal = iota
+		stepGuide    // 只前进导轨
+		stepTake     // 只前进录音
+	)
+	for i := 0; i < n; i++ {
+		for j := lo[i]; j <= hi[i]; j++ {
+			d := featureDistance(a[i], b[j])
+			if i == 0 && j == 0 {
+				cost[0][0] = d
+				continue
+			}
+			best, step := at(i-1, j-1), uint8(stepDiagonal)
+			if v := at(i-1, j); v < best {
+				best, step = v, stepGuide
+			}
+			if j > 0 {
+				if v := at(i, j-1); v < best {
+					best, step = v, stepTake
+				}
+			}
+			cost[i][j-lo[i]] = d + best
+			steps[i][j-lo[i]] = step
+		}
+	}
+
+	if math.IsInf(at(n-1, m-1), 1) {
+		return nil, 0, errors.New("no warp path within the DTW band")
+	}
+
+	var path []WarpPoint
+	i, j := n-1, m-1
+	for {
+		path = append(path, WarpPoint{Guide: i, Take: j})
+		if i == 0 && j == 0 {
+			break
+		}
+		if i < 0 || j < lo[i] || j > hi[i] {
+			return nil, 0, errors.New("warp path left the DTW band")
+		}
+		switch steps[i][j-lo[i]] {
+		case stepDiagonal:
+			i, j = i-1, j-1
+		case stepGuide:
+			i--
+		default:
+			j--
+		}
+	}
+	for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+		path[l], path[r] = path[r], path[l]
+	}
+	return path, at(n-1, m-1) / float64(len(path)), nil
+}
+
+// featureDistance 返回两帧特征的欧氏距离
+func featureDistance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,393 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// 特征矩阵均按 [帧][特征] 存储（librosa 为 [特征][帧]）
+
+// MelOptions 梅尔频谱参数，默认值与 librosa 一致
+type MelOptions struct {
+	STFT  STFTOptions
+	NMels int     // 梅尔滤波器个数，为 0 时使用 128
+	FMin  float64 // 最低频率（Hz）
+	FMax  float64 // 最高频率（Hz），为 0 时使用奈奎斯特频率
+	HTK   bool    // 使用 HTK 梅尔公式且不做 Slaney 面积归一化
+}
+
+// DefaultMelOptions 返回 librosa 默认参数（n_fft=2048, hop=512, n_mels=128）
+func DefaultMelOptions() MelOptions {
+	return MelOptions{
+		STFT:  DefaultSTFTOptions(),
+		NMels: 128,
+	}
+}
+
+// MelFilterBank 构建三角梅尔滤波器组，返回 [滤波器][频点]，
+// 非 HTK 模式下按 Slaney 方式做面积归一化（librosa norm='slaney'）
+func MelFilterBank(sampleRate, frameSize, nMels int, fmin, fmax float64, htk bool) [][]float64 {
+	bins := frameSize/2 + 1
+	fftFreqs := make([]float64, bins)
+	for k := range fftFreqs {
+		fftFreqs[k] = float64(k) * float64(sampleRate) / float64(frameSize)
+	}
+
+	// 在梅尔刻度上等间距取 nMels+2 个点
+	minMel, maxMel := HzToMel(fmin, htk), HzToMel(fmax, htk)
+	melFreqs := make([]float64, nMels+2)
+	for i := range melFreqs {
+		melFreqs[i] = MelToHz(minMel+(maxMel-minMel)*float64(i)/float64(nMels+1), htk)
+	}
+
+	weights := make([][]float64, nMels)
+	for m := range weights {
+		weights[m] = make([]float64, bins)
+		lower, center, upper := melFreqs[m], melFreqs[m+1], melFreqs[m+2]
+		for k, f := range fftFreqs {
+			rising := (f - lower) / (center - lower)
+			falling := (upper - f) / (upper - center)
+			weights[m][k] = math.Max(0, math.Min(rising, falling))
+		}
+		if !htk {
+			norm := 2 / (upper - lower)
+			for k := range weights[m] {
+				weights[m][k] *= norm
+			}
+		}
+	}
+	return weights
+}
+
+// MelSpectrogram 计算单声道混合后的梅尔功率谱
+func MelSpectrogram(segment *audio.AudioSegment, options MelOptions) ([][]float64, error) {
+	spec, err := monoSpectrogram(segment, options.STFT)
+	if err != nil {
+		return nil, err
+	}
+
+	nMels := options.NMels
+	if nMels <= 0 {
+		nMels = 128
+	}
+	fmax := options.FMax
+	if fmax <= 0 {
+		fmax = float64(spec.SampleRate) / 2
+	}
+	if options.FMin < 0 || options.FMin >= fmax {
+		return nil, errors.New("invalid mel frequency range")
+	}
+
+	filters := MelFilterBank(spec.SampleRate, spec.FrameSize, nMels, options.FMin, fmax, options.HTK)
+	return applyFilterBank(filters, spec.Power()), nil
+}
+
+// PowerToDB 将功率谱转换为分贝（10·log10），topDB 大于 0 时将低于最大值 topDB 的部分截断
+func PowerToDB(power [][]float64, topDB float64) [][]float64 {
+	db := make([][]float64, len(power))
+	peak := math.Inf(-1)
+	for i, frame := range power {
+		db[i] = make([]float64, len(frame))
+		for k, p := range frame {
+			db[i][k] = 10 * math.Log10(math.Max(p, 1e-10))
+			peak = math.Max(peak, db[i][k])
+		}
+	}
+	if topDB > 0 {
+		for _, frame := range db {
+			for k := range frame {
+				frame[k] = math.Max(frame[k], peak-topDB)
+			}
+		}
+	}
+	return db
+}
+
+// MFCC 计算梅尔倒谱系数：对数梅尔谱（top_db=80）做正交归一化的 DCT-II，nMFCC 为 0 时取 20
+func MFCC(segment *audio.AudioSegment, nMFCC int, options MelOptions) ([][]float64, error) {
+	if nMFCC <= 0 {
+		nMFCC = 20
+	}
+
+	mel, err := MelSpectrogram(segment, options)
+	if err != nil {
+		return nil, err
+	}
+	logMel := PowerToDB(mel, 80)
+
+	mfcc := make([][]float64, len(logMel))
+	for i, frame := range logMel {
+		mfcc[i] = dctII(frame, nMFCC)
+	}
+	return mfcc, nil
+}
+
+// Delta 计算特征的一阶差分（回归窗口宽度 width，奇数，为 0 时取 9），边界处重复端点帧
+func Delta(features [][]float64, width int) [][]float64 {
+	if width <= 0 {
+		width = 9
+	}
+	half := width / 2
+
+	var denom float64
+	for n := 1; n <= half; n++ {
+		denom += 2 * float64(n*n)
+	}
+
+	clamp := func(i int) int {
+		if i < 0 {
+			return 0
+		}
+		if i >= len(features) {
+			return len(features) - 1
+		}
+		return i
+	}
+
+	delta := make([][]float64, len(features))
+	for t := range features {
+		delta[t] = make([]float64, len(features[t]))
+		for n := 1; n <= half; n++ {
+			next, prev := features[clamp(t+n)], features[clamp(t-n)]
+			for k := range delta[t] {
+				delta[t][k] += float64(n) * (next[k] - prev[k])
+			}
+		}
+		for k := range delta[t] {
+			delta[t][k] /= denom
+		}
+	}
+	return delta
+}
+
+// Chroma 计算 12 维色度特征（librosa chroma_stft，tuning=0），每帧按最大值归一化
+func Chroma(segment *audio.AudioSegment, options STFTOptions) ([][]float64, error) {
+	chroma, _, err := chromaEnergy(segment, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, frame := range chroma {
+		var peak float64
+		for _, v := range frame {
+			peak = math.Max(peak, v)
+		}
+		if peak > 0 {
+			for k := range frame {
+				frame[k] /= peak
+			}
+		}
+	}
+	return chroma, nil
+}
+
+// chromaEnergy 计算未归一化的色度能量，同时返回所用的频谱
+func chromaEnergy(segment *audio.AudioSegment, options STFTOptions) ([][]float64, *Spectrogram, error) {
+	spec, err := monoSpectrogram(segment, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filters := chromaFilterBank(spec.SampleRate, spec.FrameSize, 12)
+	return applyFilterBank(filters, spec.Power()), spec, nil
+}
+
+// chromaFilterBank 构建色度滤波器组（与 librosa.filters.chroma 默认参数一致：
+// ctroct=5, octwidth=2, norm=2, base_c=True），返回 [色度][频点]
+func chromaFilterBank(sampleRate, frameSize, nChroma int) [][]float64 {
+	// 频点对应的色度位置（跳过直流分量，直流位置取第一个频点往下 1.5 个八度）
+	frqbins := make([]float64, frameSize)
+	for k := 1; k < frameSize; k++ {
+		f := float64(k) * float64(sampleRate) / float64(frameSize)
+		frqbins[k] = float64(nChroma) * math.Log2(f/(440.0/16))
+	}
+	frqbins[0] = frqbins[1] - 1.5*float64(nChroma)
+
+	binWidths := make([]float64, frameSize)
+	for k := 0; k < frameSize-1; k++ {
+		binWidths[k] = math.Max(frqbins[k+1]-frqbins[k], 1)
+	}
+	binWidths[frameSize-1] = 1
+
+	half := math.Round(float64(nChroma) / 2)
+	weights := make([][]float64, nChroma)
+	for c := range weights {
+		weights[c] = make([]float64, frameSize)
+		for k := range weights[c] {
+			d := math.Mod(frqbins[k]-float64(c)+half+10*float64(nChroma), float64(nChroma)) - half
+			weights[c][k] = math.Exp(-0.5 * math.Pow(2*d/binWidths[k], 2))
+		}
+	}
+
+	// 每个频点在色度方向做 L2 归一化，再乘以以 C5 为中心、宽 2 个八度的高斯权重
+	for k := 0; k < frameSize; k++ {
+		var norm float64
+		for c := range weights {
+			norm += weights[c][k] * weights[c][k]
+		}
+		norm = math.Sqrt(norm)
+		octave := math.Exp(-0.5 * math.Pow((frqbins[k]/float64(nChroma)-5)/2, 2))
+		for c := range weights {
+			if norm > 0 {
+				weights[c][k] /= norm
+			}
+			weights[c][k] *= octave
+		}
+	}
+
+	// 以 C 为第一个色度（从 A 起算时旋转 3 个半音），并只保留非负频率
+	bins := frameSize/2 + 1
+	shift := 3 * (nChroma / 12)
+	filters := make([][]float64, nChroma)
+	for c := range filters {
+		filters[c] = weights[(c+shift)%nChroma][:bins]
+	}
+	return filters
+}
+
+// SpectralFeatures 逐帧的频谱特征
+type SpectralFeatures struct {
+	Centroid         []float64 // 频谱质心（Hz）
+	Bandwidth        []float64 // 二阶频谱带宽（Hz）
+	Rolloff          []float64 // 85% 能量滚降频率（Hz）
+	Flatness         []float64 // 频谱平坦度（几何均值/算术均值）
+	ZeroCrossingRate []float64 // 过零率
+}
+
+// ExtractSpectralFeatures 计算单声道混合后的频谱特征，参数与 librosa 默认值一致
+func ExtractSpectralFeatures(segment *audio.AudioSegment, options STFTOptions) (*SpectralFeatures, error) {
+	spec, err := monoSpectrogram(segment, options)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := spec.Frames()
+	features := &SpectralFeatures{
+		Centroid:  make([]float64, frames),
+		Bandwidth: make([]float64, frames),
+		Rolloff:   make([]float64, frames),
+		Flatness:  make([]float64, frames),
+	}
+
+	for i, frame := range spec.Magnitude {
+		var total, weighted float64
+		for k, mag := range frame {
+			total += mag
+			weighted += mag * spec.BinFrequency(k)
+		}
+
+		if total > 0 {
+			centroid := weighted / total
+			features.Centroid[i] = centroid
+
+			var spread float64
+			for k, mag := range frame {
+				d := spec.BinFrequency(k) - centroid
+				spread += mag / total * d * d
+			}
+			features.Bandwidth[i] = math.Sqrt(spread)
+
+			var cumulative float64
+			for k, mag := range frame {
+				cumulative += mag
+				if cumulative >= 0.85*total {
+					features.Rolloff[i] = spec.BinFrequency(k)
+					break
+				}
+			}
+		}
+
+		var logSum, sum float64
+		for _, mag := range frame {
+			p := math.Max(mag*mag, 1e-10)
+			logSum += math.Log(p)
+			sum += p
+		}
+		n := float64(len(frame))
+		features.Flatness[i] = math.Exp(logSum/n) / (sum / n)
+	}
+
+	features.ZeroCrossingRate = zeroCrossingRate(monoSamples(segment), spec.FrameSize, spec.HopSize, spec.Center)
+	return features, nil
+}
+
+// zeroCrossingRate 逐帧计算过零率，居中模式下两端以边缘值填充（与 librosa 一致）
+func zeroCrossingRate(samples []float64, frameSize, hopSize int, center bool) []float64 {
+	padded := samples
+	if center {
+		pad := frameSize / 2
+		padded = make([]float64, len(samples)+2*pad)
+		copy(padded[pad:], samples)
+		for i := 0; i < pad; i++ {
+			padded[i] = samples[0]
+			padded[len(padded)-1-i] = samples[len(samples)-1]
+		}
+	}
+	if len(padded) < frameSize {
+		return []float64{0}
+	}
+
+	frames := 1 + (len(padded)-frameSize)/hopSize
+	rates := make([]float64, frames)
+	for i := range rates {
+		frame := padded[i*hopSize : i*hopSize+frameSize]
+		crossings := 0
+		for j := 1; j < len(frame); j++ {
+			// 0 视为正数
+			if (frame[j] >= 0) != (frame[j-1] >= 0) {
+				crossings++
+			}
+		}
+		rates[i] = float64(crossings) / float64(frameSize)
+	}
+	return rates
+}
+
+// monoSpectrogram 计算单声道混合后的STFT
+func monoSpectrogram(segment *audio.AudioSegment, options STFTOptions) (*Spectrogram, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+	return STFTSamples(monoSamples(segment), segment.SampleRate(), options)
+}
+
+// applyFilterBank 将滤波器组 [滤波器][频点] 应用到 [帧][频点] 的谱上
+func applyFilterBank(filters [][]float64, spectrum [][]float64) [][]float64 {
+	out := make([][]float64, len(spectrum))
+	for i, frame := range spectrum {
+		out[i] = make([]float64, len(filters))
+		for m, filter := range filters {
+			var sum float64
+			for k, w := range filter {
+				if w != 0 {
+					sum += w * frame[k]
+				}
+			}
+			out[i][m] = sum
+		}
+	}
+	return out
+}
+
+// dctII 计算正交归一化的 DCT-II，只返回前 n 个系数
+func dctII(x []float64, n int) []float64 {
+	size := len(x)
+	if n > size {
+		n = size
+	}
+	out := make([]float64, n)
+	for k := 0; k < n; k++ {
+		var sum float64
+		for i, v := range x {
+			sum += v * math.Cos(math.Pi*float64(k)*(2*float64(i)+1)/(2*float64(size)))
+		}
+		scale := math.Sqrt(2 / float64(size))
+		if k == 0 {
+			scale = math.Sqrt(1 / float64(size))
+		}
+		out[k] = sum * scale
+	}
+	return out
+}
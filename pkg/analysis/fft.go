@@ -0,0 +1,158 @@
+package analysis
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// FFT 计算任意长度复数序列的离散傅里叶变换
+// 长度为2的幂时使用基2迭代算法，否则使用 Bluestein（chirp-z）算法转换为2的幂长度的卷积
+func FFT(x []complex128) []complex128 {
+	out := make([]complex128, len(x))
+	copy(out, x)
+	transform(out, false)
+	return out
+}
+
+// IFFT 计算离散傅里叶逆变换（含 1/N 归一化）
+func IFFT(x []complex128) []complex128 {
+	out := make([]complex128, len(x))
+	copy(out, x)
+	transform(out, true)
+
+	n := complex(float64(len(out)), 0)
+	for i := range out {
+		out[i] /= n
+	}
+	return out
+}
+
+// RFFT 计算实数序列的傅里叶变换，只返回 N/2+1 个非负频率分量
+func RFFT(x []float64) []complex128 {
+	buf := make([]complex128, len(x))
+	for i, v := range x {
+		buf[i] = complex(v, 0)
+	}
+	transform(buf, false)
+	return buf[:len(x)/2+1]
+}
+
+// IRFFT 由 N/2+1 个非负频率分量重建长度为 n 的实数序列
+func IRFFT(spectrum []complex128, n int) []float64 {
+	buf := make([]complex128, n)
+	for k := 0; k < n/2+1 && k < len(spectrum); k++ {
+		buf[k] = spectrum[k]
+		if k > 0 && n-k > k {
+			buf[n-k] = cmplx.Conj(spectrum[k])
+		}
+	}
+	transform(buf, true)
+
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = real(buf[i]) / float64(n)
+	}
+	return out
+}
+
+// NextPowerOfTwo 返回不小于 n 的最小2的幂
+func NextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// transform 原地计算未归一化的正/逆变换
+func transform(x []complex128, inverse bool) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+	if n&(n-1) == 0 {
+		radix2(x, inverse)
+	} else {
+		bluestein(x, inverse)
+	}
+}
+
+// radix2 原地基2迭代FFT，长度必须为2的幂
+func radix2(x []complex128, inverse bool) {
+	n := len(x)
+
+	// 位反转重排
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		// 逐个计算旋转因子，避免连乘带来的累积误差
+		twiddles := make([]complex128, half)
+		for k := range twiddles {
+			twiddles[k] = cmplx.Rect(1, sign*2*math.Pi*float64(k)/float64(size))
+		}
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				u := x[start+k]
+				v := x[start+k+half] * twiddles[k]
+				x[start+k] = u + v
+				x[start+k+half] = u - v
+			}
+		}
+	}
+}
+
+// bluestein 使用 chirp-z 变换计算任意长度的DFT
+func bluestein(x []complex128, inverse bool) {
+	n := len(x)
+	m := NextPowerOfTwo(2*n - 1)
+
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+
+	// chirp[k] = exp(sign·iπk²/n)，k² 对 2n 取模以保持精度
+	chirp := make([]complex128, n)
+	for k := range chirp {
+		kk := (k * k) % (2 * n)
+		chirp[k] = cmplx.Rect(1, sign*math.Pi*float64(kk)/float64(n))
+	}
+
+	a := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		a[k] = x[k] * chirp[k]
+	}
+	b := make([]complex128, m)
+	b[0] = cmplx.Conj(chirp[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(chirp[k])
+		b[m-k] = cmplx.Conj(chirp[k])
+	}
+
+	radix2(a, false)
+	radix2(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	radix2(a, true)
+
+	scale := complex(float64(m), 0)
+	for k := 0; k < n; k++ {
+		x[k] = a[k] / scale * chirp[k]
+	}
+}
@@ -0,0 +1,323 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// 指纹在与采样率无关的时频网格上计算：帧长和帧移按 11025Hz 下 1024/256 样本等比缩放，
+// 频点按 11025/1024 Hz 量化，因此不同采样率的参考音频和查询片段可以互相匹配
+const (
+	fingerprintBaseRate  = 11025.0
+	fingerprintFrameSize = 1024
+	fingerprintHopSize   = 256
+	// 只使用 50Hz~5512Hz 的频点
+	fingerprintMinBin = 5
+	fingerprintMaxBin = fingerprintFrameSize / 2
+	// 峰值拾取的邻域半径（频点/帧），峰值需在全局最大值以下 60dB 以内且高出本帧中位数 10dB
+	fingerprintPeakBins   = 12
+	fingerprintPeakFrames = 8
+	fingerprintPeakRange  = 60.0
+	fingerprintPeakMedian = 10.0
+	// 目标区域：锚点之后 1~63 帧、频率相差不超过 128 个频点，每个锚点最多配对 fanOut 个峰值
+	fingerprintMaxDelta    = 63
+	fingerprintMaxBinDelta = 128
+	fingerprintFanOut      = 10
+	// 判定匹配所需的最少一致哈希数
+	fingerprintMinMatches = 5
+)
+
+// ErrNoMatch 索引中没有与查询片段匹配的参考音频
+var ErrNoMatch = errors.New("no matching reference found")
+
+// Landmark 一个地标哈希：锚点峰值、目标峰值的频点和时间差组合而成
+type Landmark struct {
+	Hash  uint32
+	Frame int // 锚点所在帧
+}
+
+// Fingerprint 音频段的地标指纹
+type Fingerprint struct {
+	Landmarks  []Landmark
+	Frames     int
+	HopSize    int // 实际帧移（样本数），按采样率缩放后取整
+	SampleRate int
+}
+
+// FrameDuration 返回指纹一帧对应的时长（按实际帧移计算，未设置时使用 11025Hz 下的名义帧移）
+func (f *Fingerprint) FrameDuration() time.Duration {
+	if f.HopSize <= 0 || f.SampleRate <= 0 {
+		return frameDuration(fingerprintHopSize, fingerprintBaseRate)
+	}
+	return frameDuration(f.HopSize, float64(f.SampleRate))
+}
+
+// frameDuration 返回 hopSize 个样本对应的时长
+func frameDuration(hopSize int, sampleRate float64) time.Duration {
+	seconds := float64(hopSize) / sampleRate
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// ComputeFingerprint 计算音频段（单声道混合）的地标指纹
+func ComputeFingerprint(segment *audio.AudioSegment) (*Fingerprint, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+
+	scale := float64(segment.SampleRate()) / fingerprintBaseRate
+	hopSize := int(math.Round(fingerprintHopSize * scale))
+	spec, err := STFTSamples(monoSamples(segment), segment.SampleRate(), STFTOptions{
+		FrameSize: int(math.Round(fingerprintFrameSize * scale)),
+		HopSize:   hopSize,
+		Window:    Hann,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	peaks := constellation(fingerprintGrid(spec))
+	return &Fingerprint{
+		Landmarks:  landmarks(peaks),
+		Frames:     spec.Frames(),
+		HopSize:    hopSize,
+		SampleRate: segment.SampleRate(),
+	}, nil
+}
+
+// fingerprintGrid 将频谱重采样到统一的频点网格，并转换为 dB
+func fingerprintGrid(spec *Spectrogram) [][]float64 {
+	binHz := fingerprintBaseRate / fingerprintFrameSize
+	grid := make([][]float64, spec.Frames())
+	for i, frame := range spec.Magnitude {
+		grid[i] = make([]float64, fingerprintMaxBin+1)
+		for k := range grid[i] {
+			// 取最近的实际频点
+			src := int(math.Round(float64(k) * binHz * float64(spec.FrameSize) / float64(spec.SampleRate)))
+			if src >= len(frame) {
+				src = len(frame) - 1
+			}
+			grid[i][k] = 20 * math.Log10(frame[src]+1e-10)
+		}
+	}
+	return grid
+}
+
+// peak 时频平面上的一个峰值
+type peak struct {
+	frame, bin int
+}
+
+// constellation 在时频平面上拾取局部最大值（星座图），按时间和频率排序
+func constellation(grid [][]float64) []peak {
+	if len(grid) == 0 {
+		return nil
+	}
+	bins := len(grid[0])
+
+	globalMax := math.Inf(-1)
+	for _, frame := range grid {
+		for _, v := range frame {
+			globalMax = math.Max(globalMax, v)
+		}
+	}
+	floor := math.Max(globalMax-fingerprintPeakRange, -180)
+
+	// 可分离的二维最大值滤波：先沿频率，再沿时间
+	freqMax := make([][]float64, len(grid))
+	for i, frame := range grid {
+		freqMax[i] = slidingMax(frame, fingerprintPeakBins)
+	}
+	localMax := make([][]float64, len(grid))
+	for i := range localMax {
+		localMax[i] = make([]float64, bins)
+	}
+	column := make([]float64, len(grid))
+	for k := 0; k < bins; k++ {
+		for i := range grid {
+			column[i] = freqMax[i][k]
+		}
+		for i, v := range slidingMax(column, fingerprintPeakFrames) {
+			localMax[i][k] = v
+		}
+	}
+
+	var peaks []peak
+	sorted := make([]float64, bins)
+	for i, frame := range grid {
+		copy(sorted, frame)
+		sort.Float64s(sorted)
+		threshold := math.Max(floor, sorted[bins/2]+fingerprintPeakMedian)
+		for k := fingerprintMinBin; k < bins; k++ {
+			if frame[k] > threshold && frame[k] == localMax[i][k] {
+				peaks = append(peaks, peak{frame: i, bin: k})
+			}
+		}
+	}
+	return peaks
+}
+
+// slidingMax 返回每个位置半径 radius 范围内的最大值
+func slidingMax(values []float64, radius int) []float64 {
+	out := make([]float64, len(values))
+	for i := range values {
+		lo, hi := i-radius, i+radius
+		if lo < 0 {
+			lo = 0
+		}
+		if hi >= len(values) {
+			hi = len(values) - 1
+		}
+		m := values[lo]
+		for _, v := range values[lo+1 : hi+1] {
+			m = math.Max(m, v)
+		}
+		out[i] = m
+	}
+	return out
+}
+
+// landmarks 将每个锚点与其目标区域内的峰值配对生成哈希
+func landmarks(peaks []peak) []Landmark {
+	var result []Landmark
+	for i, anchor := range peaks {
+		paired := 0
+		for _, target := range peaks[i+1:] {
+			dt := target.frame - anchor.frame
+			if dt > fingerprintMaxDelta {
+				break
+			}
+			if dt < 1 {
+				continue
+			}
+			if df := target.bin - anchor.bin; df < -fingerprintMaxBinDelta || df > fingerprintMaxBinDelta {
+				continue
+			}
+			result = append(result, Landmark{
+				Hash:  landmarkHash(anchor.bin, target.bin, dt),
+				Frame: anchor.frame,
+			})
+			paired++
+			if paired == fingerprintFanOut {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// landmarkHash 按 锚点频点(10位) | 目标频点(10位) | 时间差(6位) 组合哈希
+func landmarkHash(anchorBin, targetBin, dt int) uint32 {
+	return uint32(anchorBin)<<16 | uint32(targetBin)<<6 | uint32(dt)
+}
+
+// FingerprintMatch 匹配结果
+type FingerprintMatch struct {
+	ID         string
+	Offset     time.Duration // 查询片段在参考音频中的起始位置
+	Confidence float64       // 一致哈希数占查询哈希数的比例（0~1）
+	Matches    int           // 时间偏移一致的哈希数
+}
+
+// posting 倒排索引项
+type posting struct {
+	ref   int
+	frame int
+}
+
+// FingerprintIndex 内存中的指纹索引，可并发使用
+type FingerprintIndex struct {
+	mu       sync.RWMutex
+	ids      []string
+	frames   []time.Duration // 每个参考音频指纹一帧的实际时长
+	postings map[uint32][]posting
+}
+
+// NewFingerprintIndex 创建空的指纹索引
+func NewFingerprintIndex() *FingerprintIndex {
+	return &FingerprintIndex{
+		postings: make(map[uint32][]posting),
+	}
+}
+
+// Add 计算参考音频的指纹并加入索引
+func (ix *FingerprintIndex) Add(id string, segment *audio.AudioSegment) error {
+	fingerprint, err := ComputeFingerprint(segment)
+	if err != nil {
+		return err
+	}
+	ix.AddFingerprint(id, fingerprint)
+	return nil
+}
+
+// AddFingerprint 将已计算的指纹加入索引
+func (ix *FingerprintIndex) AddFingerprint(id string, fingerprint *Fingerprint) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ref := len(ix.ids)
+	ix.ids = append(ix.ids, id)
+	ix.frames = append(ix.frames, fingerprint.FrameDuration())
+	for _, landmark := range fingerprint.Landmarks {
+		ix.postings[landmark.Hash] = append(ix.postings[landmark.Hash], posting{ref: ref, frame: landmark.Frame})
+	}
+}
+
+// Len 返回索引中的参考音频数量
+func (ix *FingerprintIndex) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.ids)
+}
+
+// Match 在索引中查找与查询片段最匹配的参考音频
+func (ix *FingerprintIndex) Match(query *audio.AudioSegment) (*FingerprintMatch, error) {
+	fingerprint, err := ComputeFingerprint(query)
+	if err != nil {
+		return nil, err
+	}
+	return ix.MatchFingerprint(fingerprint)
+}
+
+// MatchFingerprint 在索引中查找与查询指纹最匹配的参考音频
+func (ix *FingerprintIndex) MatchFingerprint(fingerprint *Fingerprint) (*FingerprintMatch, error) {
+	if len(fingerprint.Landmarks) == 0 {
+		return nil, ErrNoMatch
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// 对每个参考音频统计 (参考帧 - 查询帧) 的直方图，真正的匹配会集中在同一偏移上
+	type key struct{ ref, offset int }
+	histogram := make(map[key]int)
+	for _, landmark := range fingerprint.Landmarks {
+		for _, p := range ix.postings[landmark.Hash] {
+			histogram[key{p.ref, p.frame - landmark.Frame}]++
+		}
+	}
+
+	var best key
+	bestCount := 0
+	for k := range histogram {
+		// 相邻偏移合并计数，容忍查询与参考帧网格不对齐造成的 ±1 帧抖动
+		count := histogram[k] + histogram[key{k.ref, k.offset - 1}] + histogram[key{k.ref, k.offset + 1}]
+		if count > bestCount || count == bestCount && (k.ref < best.ref || k.ref == best.ref && k.offset < best.offset) {
+			best, bestCount = k, count
+		}
+	}
+	if bestCount < fingerprintMinMatches {
+		return nil, ErrNoMatch
+	}
+
+	return &FingerprintMatch{
+		ID:         ix.ids[best.ref],
+		Offset:     time.Duration(best.offset) * ix.frames[best.ref],
+		Confidence: math.Min(1, float64(bestCount)/float64(len(fingerprint.Landmarks))),
+		Matches:    bestCount,
+	}, nil
+}
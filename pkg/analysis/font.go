@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"image"
+	"image/color"
+)
+
+// 3x5 点阵字体，用于在图像上绘制坐标轴刻度，每行用低3位表示
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'.': {0, 0, 0, 0, 2},
+	'-': {0, 0, 7, 0, 0},
+	'+': {0, 2, 7, 2, 0},
+	'k': {4, 5, 6, 5, 5},
+	's': {0, 3, 6, 1, 6},
+	'L': {4, 4, 4, 4, 7},
+	'R': {6, 5, 6, 5, 5},
+	'M': {5, 7, 7, 5, 5},
+	'S': {3, 4, 2, 1, 6},
+}
+
+const (
+	glyphWidth   = 3
+	glyphHeight  = 5
+	glyphSpacing = 1
+)
+
+// textWidth 返回文字的像素宽度
+func textWidth(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return n*(glyphWidth+glyphSpacing) - glyphSpacing
+}
+
+// drawText 以 (x, y) 为左上角绘制文字，不支持的字符留空
+func drawText(img *image.RGBA, x, y int, text string, c color.Color) {
+	for _, r := range text {
+		if glyph, ok := glyphs[r]; ok {
+			for row, bits := range glyph {
+				for col := 0; col < glyphWidth; col++ {
+					if bits&(1<<(glyphWidth-1-col)) != 0 {
+						img.Set(x+col, y+row, c)
+					}
+				}
+			}
+		}
+		x += glyphWidth + glyphSpacing
+	}
+}
+
+// fillRect 填充矩形区域
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.Color) {
+	rect = rect.Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// PitchClass 音级，0 为 C，11 为 B
+type PitchClass int
+
+var pitchClassNames = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// String 返回音级名称
+func (p PitchClass) String() string {
+	return pitchClassNames[((int(p)%12)+12)%12]
+}
+
+// KeyMode 调式
+type KeyMode int
+
+const (
+	// Major 大调
+	Major KeyMode = iota
+	// Minor 小调
+	Minor
+)
+
+// String 返回调式名称
+func (m KeyMode) String() string {
+	if m == Minor {
+		return "minor"
+	}
+	return "major"
+}
+
+// Krumhansl-Kessler 调性轮廓（以主音为第 0 个音级）
+var (
+	majorProfile = []float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = []float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// keySTFTOptions 调性和和弦识别使用的STFT参数（较长的帧以提高低频分辨率）
+var keySTFTOptions = STFTOptions{
+	FrameSize: 4096,
+	HopSize:   2048,
+	Window:    Hann,
+	Center:    true,
+}
+
+// KeyCandidate 一个候选调性及其与调性轮廓的相关系数
+type KeyCandidate struct {
+	Tonic       PitchClass
+	Mode        KeyMode
+	Correlation float64
+}
+
+// String 返回调性名称，如 "A minor"
+func (k KeyCandidate) String() string {
+	return k.Tonic.String() + " " + k.Mode.String()
+}
+
+// Key 调性估计结果
+type Key struct {
+	KeyCandidate
+	// Confidence 最佳候选相对次佳候选的领先程度（0~1），关系大小调难以区分时较低
+	Confidence float64
+	// Candidates 全部 24 个候选，按相关系数从高到低排列
+	Candidates []KeyCandidate
+}
+
+// EstimateKey 用 Krumhansl-Schmuckler 算法估计调性：将整段的色度分布与 24 个大小调轮廓求相关
+func EstimateKey(segment *audio.AudioSegment) (*Key, error) {
+	chroma, _, err := chromaEnergy(segment, keySTFTOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := make([]float64, 12)
+	for _, frame := range chroma {
+		for c, v := range frame {
+			distribution[c] += v
+		}
+	}
+	// 静音或各音级能量相同时与所有轮廓的相关系数都为 0，无法判断调性
+	lowest, highest := distribution[0], distribution[0]
+	for _, v := range distribution {
+		lowest, highest = math.Min(lowest, v), math.Max(highest, v)
+	}
+	if highest-lowest <= 1e-12*highest {
+		return nil, errors.New("no tonal content to estimate key")
+	}
+
+	candidates := make([]KeyCandidate, 0, 24)
+	for _, mode := range []KeyMode{Major, Minor} {
+		profile := majorProfile
+		if mode == Minor {
+			profile = minorProfile
+		}
+		for tonic := 0; tonic < 12; tonic++ {
+			rotated := make([]float64, 12)
+			for c := range rotated {
+				rotated[c] = profile[(c-tonic+12)%12]
+			}
+			candidates = append(candidates, KeyCandidate{
+				Tonic:       PitchClass(tonic),
+				Mode:        mode,
+				Correlation: pearson(distribution, rotated),
+			})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Correlation > candidates[j].Correlation
+	})
+
+	best, second := candidates[0].Correlation, candidates[1].Correlation
+	confidence := 0.0
+	if best > 0 && best < 1 {
+		confidence = (best - second) / (1 - second)
+	} else if best >= 1 {
+		confidence = 1
+	}
+
+	return &Key{
+		KeyCandidate: candidates[0],
+		Confidence:   math.Max(0, math.Min(1, confidence)),
+		Candidates:   candidates,
+	}, nil
+}
+
+// pearson 返回两个序列的皮尔逊相关系数
+func pearson(a, b []float64) float64 {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(len(a))
+	meanB /= float64(len(b))
+
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// ChordQuality 和弦性质
+type ChordQuality int
+
+const (
+	// NoChord 无和弦（静音或无法识别）
+	NoChord ChordQuality = iota
+	// MajorChord 大三和弦
+	MajorChord
+	// MinorChord 小三和弦
+	MinorChord
+)
+
+// Chord 和弦
+type Chord struct {
+	Root    PitchClass
+	Quality ChordQuality
+}
+
+// String 返回和弦记号，如 "C"、"Am"，无和弦为 "N"
+func (c Chord) String() string {
+	switch c.Quality {
+	case MajorChord:
+		return c.Root.String()
+	case MinorChord:
+		return c.Root.String() + "m"
+	default:
+		return "N"
+	}
+}
+
+// ChordFrame 一帧的和弦识别结果
+type ChordFrame struct {
+	Time  time.Duration
+	Chord Chord
+	Score float64 // 色度与和弦模板的余弦相似度
+}
+
+const (
+	// 色度在时间上的平滑半径（帧）
+	chordSmoothing = 2
+	// 低于最大帧能量该比例的帧视为静音
+	chordSilence = 1e-4
+	// 相似度低于该值时判为无和弦
+	chordMinScore = 0.5
+)
+
+// RecognizeChords 逐帧识别大三/小三和弦：平滑后的色度与 24 个三和弦模板求余弦相似度
+func RecognizeChords(segment *audio.AudioSegment) ([]ChordFrame, error) {
+	chroma, spec, err := chromaEnergy(segment, keySTFTOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	energies := make([]float64, len(chroma))
+	var peak float64
+	for i, frame := range chroma {
+		for _, v := range frame {
+			energies[i] += v
+		}
+		peak = math.Max(peak, energies[i])
+	}
+
+	frames := make([]ChordFrame, len(chroma))
+	for i := range chroma {
+		frames[i] = ChordFrame{Time: spec.FrameTime(i)}
+		if energies[i] <= chordSilence*peak {
+			continue
+		}
+
+		// 相邻帧的色度（各自归一化后）取平均，抑制瞬态
+		smoothed := make([]float64, 12)
+		for j := max(0, i-chordSmoothing); j <= min(len(chroma)-1, i+chordSmoothing); j++ {
+			if energies[j] <= 0 {
+				continue
+			}
+			for c, v := range chroma[j] {
+				smoothed[c] += v / energies[j]
+			}
+		}
+
+		for root := 0; root < 12; root++ {
+			for _, quality := range []ChordQuality{MajorChord, MinorChord} {
+				third := 4
+				if quality == MinorChord {
+					third = 3
+				}
+				score := triadScore(smoothed, root, third)
+				if score > frames[i].Score {
+					frames[i].Chord = Chord{Root: PitchClass(root), Quality: quality}
+					frames[i].Score = score
+				}
+			}
+		}
+		if frames[i].Score < chordMinScore {
+			frames[i].Chord = Chord{}
+		}
+	}
+	return frames, nil
+}
+
+// triadScore 计算色度与三和弦（根音、三度、五度）二值模板的余弦相似度
+func triadScore(chroma []float64, root, third int) float64 {
+	var dot, norm float64
+	for _, v := range chroma {
+		norm += v * v
+	}
+	for _, interval := range []int{0, third, 7} {
+		dot += chroma[(root+interval)%12]
+	}
+	if norm == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(norm) * math.Sqrt(3))
+}
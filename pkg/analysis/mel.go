@@ -0,0 +1,33 @@
+package analysis
+
+import "math"
+
+// Slaney 梅尔刻度参数：1000Hz 以下线性，以上对数
+const (
+	melFSp       = 200.0 / 3
+	melMinLogHz  = 1000.0
+	melMinLogMel = melMinLogHz / melFSp
+	melLogStep   = 0.06875177742094912 // ln(6.4) / 27
+)
+
+// HzToMel 将频率转换为梅尔值，htk 为 true 时使用 HTK 公式，否则使用 Slaney 公式（librosa 默认）
+func HzToMel(freq float64, htk bool) float64 {
+	if htk {
+		return 2595 * math.Log10(1+freq/700)
+	}
+	if freq < melMinLogHz {
+		return freq / melFSp
+	}
+	return melMinLogMel + math.Log(freq/melMinLogHz)/melLogStep
+}
+
+// MelToHz 将梅尔值转换为频率
+func MelToHz(mel float64, htk bool) float64 {
+	if htk {
+		return 700 * (math.Pow(10, mel/2595) - 1)
+	}
+	if mel < melMinLogMel {
+		return mel * melFSp
+	}
+	return melMinLogHz * math.Exp(melLogStep*(mel-melMinLogMel))
+}
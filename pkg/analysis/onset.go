@@ -0,0 +1,168 @@
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// OnsetMethod 起始点检测函数
+type OnsetMethod int
+
+const (
+	// OnsetSpectralFlux 对数压缩幅度谱的正向差分（谱通量）
+	OnsetSpectralFlux OnsetMethod = iota
+	// OnsetEnergy 帧能量的正向差分
+	OnsetEnergy
+	// OnsetHFC 高频内容（按频点加权的能量）的正向差分，适合打击乐
+	OnsetHFC
+)
+
+// 起始点检测使用的STFT参数
+var onsetSTFTOptions = STFTOptions{
+	FrameSize: 1024,
+	HopSize:   256,
+	Window:    Hann,
+	Center:    true,
+}
+
+const (
+	// 峰值拾取的局部最大值窗口和均值窗口（帧）
+	onsetMaxWindow  = 3
+	onsetMeanWindow = 10
+	// 相邻起始点的最小间隔
+	onsetMinGap = 30 * time.Millisecond
+)
+
+// OnsetEnvelope 起始点强度包络，每帧一个值并归一化到 [0, 1]
+type OnsetEnvelope struct {
+	Strength   []float64
+	SampleRate int
+	HopSize    int
+}
+
+// FrameTime 返回第 i 帧的时间
+func (e *OnsetEnvelope) FrameTime(i int) time.Duration {
+	return time.Duration(float64(i*e.HopSize) / float64(e.SampleRate) * float64(time.Second))
+}
+
+// FrameRate 返回包络的帧率（帧/秒）
+func (e *OnsetEnvelope) FrameRate() float64 {
+	return float64(e.SampleRate) / float64(e.HopSize)
+}
+
+// OnsetStrength 计算单声道混合后的起始点强度包络
+func OnsetStrength(segment *audio.AudioSegment, method OnsetMethod) (*OnsetEnvelope, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+
+	spec, err := STFTSamples(monoSamples(segment), segment.SampleRate(), onsetSTFTOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每帧的检测特征
+	features := make([][]float64, spec.Frames())
+	for i, frame := range spec.Magnitude {
+		switch method {
+		case OnsetEnergy:
+			var energy float64
+			for _, mag := range frame {
+				energy += mag * mag
+			}
+			features[i] = []float64{energy}
+		case OnsetHFC:
+			var hfc float64
+			for k, mag := range frame {
+				hfc += float64(k) * mag * mag
+			}
+			features[i] = []float64{hfc}
+		default:
+			compressed := make([]float64, len(frame))
+			for k, mag := range frame {
+				compressed[k] = math.Log1p(1000 * mag)
+			}
+			features[i] = compressed
+		}
+	}
+
+	strength := make([]float64, len(features))
+	var peak float64
+	for i := 1; i < len(features); i++ {
+		var sum float64
+		for k, v := range features[i] {
+			if d := v - features[i-1][k]; d > 0 {
+				sum += d
+			}
+		}
+		strength[i] = sum
+		peak = math.Max(peak, sum)
+	}
+	if peak > 0 {
+		for i := range strength {
+			strength[i] /= peak
+		}
+	}
+
+	return &OnsetEnvelope{
+		Strength:   strength,
+		SampleRate: spec.SampleRate,
+		HopSize:    spec.HopSize,
+	}, nil
+}
+
+// Onsets 检测起始点时间，sensitivity 取值 [0, 1]，越大检测到的起始点越多
+func Onsets(segment *audio.AudioSegment, method OnsetMethod, sensitivity float64) ([]time.Duration, error) {
+	if sensitivity < 0 || sensitivity > 1 {
+		return nil, errors.New("sensitivity must be in [0, 1]")
+	}
+
+	envelope, err := OnsetStrength(segment, method)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := 0.02 + 0.4*(1-sensitivity)
+	minGap := int(math.Ceil(onsetMinGap.Seconds() * envelope.FrameRate()))
+	strength := envelope.Strength
+
+	var onsets []time.Duration
+	last := -minGap
+	for i, v := range strength {
+		if v <= 0 || i-last < minGap {
+			continue
+		}
+
+		// 局部最大值
+		isPeak := true
+		for j := i - onsetMaxWindow; j <= i+onsetMaxWindow; j++ {
+			if j >= 0 && j < len(strength) && strength[j] > v {
+				isPeak = false
+				break
+			}
+		}
+		if !isPeak {
+			continue
+		}
+
+		// 高于局部均值加偏移量
+		var sum float64
+		var count int
+		for j := i - onsetMeanWindow; j <= i+onsetMaxWindow; j++ {
+			if j >= 0 && j < len(strength) {
+				sum += strength[j]
+				count++
+			}
+		}
+		if v < sum/float64(count)+delta {
+			continue
+		}
+
+		onsets = append(onsets, envelope.FrameTime(i))
+		last = i
+	}
+	return onsets, nil
+}
@@ -0,0 +1,246 @@
+package analysis
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// YIN 累积均值归一化差分函数的判定阈值
+const yinThreshold = 0.15
+
+// PitchFrame 单帧的基频估计结果
+type PitchFrame struct {
+	Time       time.Duration // 帧中心时间
+	Frequency  float64       // 基频（Hz），清音帧为 0
+	Confidence float64       // 周期性置信度 [0, 1]，即 1 - CMNDF 最小值
+	Voiced     bool          // 是否为浊音
+}
+
+// PitchTrack 基频轨迹
+type PitchTrack struct {
+	Frames []PitchFrame
+	Hop    time.Duration
+}
+
+// PitchStats 基频统计（仅统计浊音帧）
+type PitchStats struct {
+	Median      float64 // 中位基频（Hz）
+	Mean        float64 // 平均基频（Hz）
+	Min         float64 // 最低基频（Hz）
+	Max         float64 // 最高基频（Hz）
+	Range       float64 // 音域（半音）
+	VoicedRatio float64 // 浊音帧占比
+}
+
+// Stats 计算基频统计，没有浊音帧时各项为 0
+func (t *PitchTrack) Stats() PitchStats {
+	var voiced []float64
+	for _, frame := range t.Frames {
+		if frame.Voiced {
+			voiced = append(voiced, frame.Frequency)
+		}
+	}
+	if len(voiced) == 0 {
+		return PitchStats{}
+	}
+
+	sort.Float64s(voiced)
+	var sum float64
+	for _, f := range voiced {
+		sum += f
+	}
+
+	stats := PitchStats{
+		Mean:        sum / float64(len(voiced)),
+		Min:         voiced[0],
+		Max:         voiced[len(voiced)-1],
+		VoicedRatio: float64(len(voiced)) / float64(len(t.Frames)),
+	}
+	mid := len(voiced) / 2
+	if len(voiced)%2 == 0 {
+		stats.Median = (voiced[mid-1] + voiced[mid]) / 2
+	} else {
+		stats.Median = voiced[mid]
+	}
+	stats.Range = 12 * math.Log2(stats.Max/stats.Min)
+	return stats
+}
+
+// PitchContour 使用 YIN 算法逐帧估计基频，多声道音频会先混合为单声道
+// minHz/maxHz 为搜索范围，hop 为帧移
+func PitchContour(segment *audio.AudioSegment, minHz, maxHz float64, hop time.Duration) (*PitchTrack, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+	sampleRate := segment.SampleRate()
+	if minHz <= 0 || maxHz <= minHz {
+		return nil, errors.New("invalid pitch range")
+	}
+	if maxHz >= float64(sampleRate)/2 {
+		return nil, errors.New("maximum pitch must be below the nyquist frequency")
+	}
+	hopSize := int(hop.Seconds() * float64(sampleRate))
+	if hopSize <= 0 {
+		return nil, errors.New("hop must be at least one sample")
+	}
+
+	samples := monoSamples(segment)
+	tauMin := int(math.Floor(float64(sampleRate) / maxHz))
+	tauMax := int(math.Ceil(float64(sampleRate) / minHz))
+	if tauMin < 2 {
+		tauMin = 2
+	}
+	// 积分窗长度取最大周期，帧长为积分窗加最大延迟
+	window := tauMax
+	frameSize := window + tauMax + 1
+
+	frames := 1
+	if len(samples) > frameSize {
+		frames = 1 + (len(samples)-frameSize+hopSize-1)/hopSize
+	}
+
+	yin := newYIN(window, tauMax)
+	frame := make([]float64, frameSize)
+	track := &PitchTrack{
+		Frames: make([]PitchFrame, frames),
+		Hop:    hop,
+	}
+	for i := 0; i < frames; i++ {
+		start := i * hopSize
+		for j := range frame {
+			frame[j] = 0
+			if start+j < len(samples) {
+				frame[j] = samples[start+j]
+			}
+		}
+
+		tau, value := yin.estimate(frame, tauMin)
+		center := start + window/2
+		result := PitchFrame{
+			Time:       time.Duration(float64(center) / float64(sampleRate) * float64(time.Second)),
+			Confidence: math.Max(0, math.Min(1, 1-value)),
+		}
+		if tau > 0 && value < yinThreshold {
+			result.Voiced = true
+			result.Frequency = float64(sampleRate) / tau
+		}
+		track.Frames[i] = result
+	}
+
+	return track, nil
+}
+
+// yin 在各帧之间复用 FFT 和差分缓冲区的 YIN 估计器
+type yin struct {
+	window int
+	tauMax int
+	size   int
+	a, b   []complex128
+	energy []float64
+	diff   []float64
+	cmndf  []float64
+}
+
+func newYIN(window, tauMax int) *yin {
+	size := NextPowerOfTwo(2*window + tauMax)
+	return &yin{
+		window: window,
+		tauMax: tauMax,
+		size:   size,
+		a:      make([]complex128, size),
+		b:      make([]complex128, size),
+		energy: make([]float64, tauMax+1),
+		diff:   make([]float64, tauMax+1),
+		cmndf:  make([]float64, tauMax+1),
+	}
+}
+
+// estimate 返回插值后的最佳周期（样本数）及对应的 CMNDF 值，静音帧返回 0 周期
+func (y *yin) estimate(frame []float64, tauMin int) (float64, float64) {
+	w := y.window
+
+	// 差分函数 d(τ) = Σ(x[j] - x[j+τ])² = e(0) + e(τ) - 2r(τ)，r 通过FFT互相关计算
+	a, b := y.a, y.b
+	clear(a)
+	clear(b)
+	for j := 0; j < w; j++ {
+		a[j] = complex(frame[j], 0)
+	}
+	for j := 0; j < w+y.tauMax && j < len(frame); j++ {
+		b[j] = complex(frame[j], 0)
+	}
+	transform(a, false)
+	transform(b, false)
+	for i := range a {
+		a[i] = cmplx.Conj(a[i]) * b[i]
+	}
+	transform(a, true)
+
+	// 滑动窗口能量
+	energy := y.energy
+	var e float64
+	for j := 0; j < w; j++ {
+		e += frame[j] * frame[j]
+	}
+	energy[0] = e
+	for tau := 1; tau <= y.tauMax; tau++ {
+		e += frame[tau+w-1]*frame[tau+w-1] - frame[tau-1]*frame[tau-1]
+		energy[tau] = e
+	}
+	if energy[0] < 1e-10 {
+		return 0, 1
+	}
+
+	scale := float64(y.size)
+	y.diff[0] = 0
+	for tau := 1; tau <= y.tauMax; tau++ {
+		y.diff[tau] = math.Max(0, energy[0]+energy[tau]-2*real(a[tau])/scale)
+	}
+
+	// 累积均值归一化
+	y.cmndf[0] = 1
+	var running float64
+	for tau := 1; tau <= y.tauMax; tau++ {
+		running += y.diff[tau]
+		if running == 0 {
+			y.cmndf[tau] = 1
+		} else {
+			y.cmndf[tau] = y.diff[tau] * float64(tau) / running
+		}
+	}
+
+	// 取第一个低于阈值的谷底，否则取全局最小值
+	best := -1
+	for tau := tauMin; tau <= y.tauMax; tau++ {
+		if y.cmndf[tau] < yinThreshold {
+			for tau+1 <= y.tauMax && y.cmndf[tau+1] < y.cmndf[tau] {
+				tau++
+			}
+			best = tau
+			break
+		}
+	}
+	if best < 0 {
+		best = tauMin
+		for tau := tauMin; tau <= y.tauMax; tau++ {
+			if y.cmndf[tau] < y.cmndf[best] {
+				best = tau
+			}
+		}
+	}
+
+	// 抛物线插值得到亚样本精度的周期
+	period := float64(best)
+	if best > tauMin && best < y.tauMax {
+		s0, s1, s2 := y.cmndf[best-1], y.cmndf[best], y.cmndf[best+1]
+		if denom := s0 - 2*s1 + s2; denom != 0 {
+			period += (s0 - s2) / (2 * denom)
+		}
+	}
+	return period, y.cmndf[best]
+}
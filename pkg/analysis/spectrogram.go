@@ -0,0 +1,318 @@
+package analysis
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// FrequencyScale 频率轴刻度
+type FrequencyScale int
+
+const (
+	// ScaleLinear 线性频率刻度
+	ScaleLinear FrequencyScale = iota
+	// ScaleLog 对数频率刻度
+	ScaleLog
+	// ScaleMel 梅尔频率刻度
+	ScaleMel
+)
+
+// SpectrogramOptions 频谱图渲染参数
+type SpectrogramOptions struct {
+	STFT         STFTOptions    // STFT 参数
+	Width        int            // 绘图区宽度（像素），为 0 时等于帧数（最多 2000）
+	Height       int            // 每个声道的绘图区高度（像素），为 0 时使用 256
+	Scale        FrequencyScale // 频率刻度
+	MinFrequency float64        // 最低频率（Hz），为 0 时线性/梅尔刻度从 0 开始，对数刻度从 20Hz 开始
+	MaxFrequency float64        // 最高频率（Hz），为 0 时使用奈奎斯特频率
+	DynamicRange float64        // 显示的动态范围（dB，相对于最大值），为 0 时使用 80
+	Colormap     Colormap       // 配色
+	Axes         bool           // 是否绘制时间和频率坐标轴
+}
+
+const (
+	// 坐标轴区域尺寸和声道间隔（像素）
+	axisLeftMargin   = 24
+	axisBottomMargin = 10
+	channelSeparator = 2
+	// 刻度标签之间的最小间距（像素）
+	minTickSpacing = 40
+)
+
+// 坐标轴颜色
+var (
+	axisBackground = color.RGBA{A: 255}
+	axisForeground = color.RGBA{R: 220, G: 220, B: 220, A: 255}
+)
+
+// RenderSpectrogram 计算音频段的STFT并将频谱图以PNG格式写入 w，多个声道自上而下堆叠
+func RenderSpectrogram(segment *audio.AudioSegment, w io.Writer, options SpectrogramOptions) error {
+	specs, err := STFT(segment, options.STFT)
+	if err != nil {
+		return err
+	}
+
+	img, err := renderSpectrogramImage(specs, options)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// renderSpectrogramImage 将频谱绘制为图像
+func renderSpectrogramImage(specs []*Spectrogram, options SpectrogramOptions) (*image.RGBA, error) {
+	first := specs[0]
+	nyquist := float64(first.SampleRate) / 2
+
+	width := options.Width
+	if width <= 0 {
+		width = first.Frames()
+		if width > 2000 {
+			width = 2000
+		}
+	}
+	height := options.Height
+	if height <= 0 {
+		height = 256
+	}
+	dynamicRange := options.DynamicRange
+	if dynamicRange <= 0 {
+		dynamicRange = 80
+	}
+	maxFreq := options.MaxFrequency
+	if maxFreq <= 0 || maxFreq > nyquist {
+		maxFreq = nyquist
+	}
+	minFreq := options.MinFrequency
+	if options.Scale == ScaleLog && minFreq <= 0 {
+		minFreq = math.Max(20, first.BinFrequency(1))
+	}
+	if minFreq < 0 || minFreq >= maxFreq {
+		return nil, errors.New("invalid frequency range")
+	}
+
+	scale := newFrequencyAxis(options.Scale, minFreq, maxFreq)
+
+	// 以所有声道的最大幅度为 0dB 参考
+	var ref float64
+	for _, spec := range specs {
+		for _, frame := range spec.Magnitude {
+			for _, mag := range frame {
+				ref = math.Max(ref, mag)
+			}
+		}
+	}
+	if ref == 0 {
+		ref = 1
+	}
+
+	left, bottom := 0, 0
+	if options.Axes {
+		left, bottom = axisLeftMargin, axisBottomMargin
+	}
+	totalHeight := len(specs)*height + (len(specs)-1)*channelSeparator + bottom
+	img := image.NewRGBA(image.Rect(0, 0, left+width, totalHeight))
+	fillRect(img, img.Bounds(), axisBackground)
+
+	binWidth := float64(first.SampleRate) / float64(first.FrameSize)
+	column := make([]float64, first.Bins())
+	for ch, spec := range specs {
+		top := ch * (height + channelSeparator)
+		frames := spec.Frames()
+
+		for x := 0; x < width; x++ {
+			// 多帧映射到同一列时取最大值
+			from := x * frames / width
+			to := (x + 1) * frames / width
+			if to <= from {
+				to = from + 1
+			}
+			for k := range column {
+				column[k] = 0
+			}
+			for i := from; i < to && i < frames; i++ {
+				for k, mag := range spec.Magnitude[i] {
+					column[k] = math.Max(column[k], mag)
+				}
+			}
+
+			for y := 0; y < height; y++ {
+				// 像素行对应的频率区间
+				high := scale.frequency(1 - float64(y)/float64(height))
+				low := scale.frequency(1 - float64(y+1)/float64(height))
+				mag := sampleBins(column, low/binWidth, high/binWidth)
+
+				db := 20 * math.Log10(math.Max(mag/ref, 1e-12))
+				img.Set(left+x, top+y, options.Colormap.Color((db+dynamicRange)/dynamicRange))
+			}
+		}
+
+		if options.Axes {
+			drawFrequencyAxis(img, scale, top, height)
+		}
+	}
+
+	if options.Axes {
+		duration := float64(first.Length) / float64(first.SampleRate)
+		drawTimeAxis(img, left, width, totalHeight-bottom, duration)
+	}
+
+	return img, nil
+}
+
+// sampleBins 取频点区间 [low, high] 的幅度：跨越多个频点时取最大值，否则线性插值
+func sampleBins(bins []float64, low, high float64) float64 {
+	last := float64(len(bins) - 1)
+	low = math.Max(0, math.Min(last, low))
+	high = math.Max(0, math.Min(last, high))
+
+	if int(high)-int(math.Ceil(low)) >= 1 {
+		peak := 0.0
+		for k := int(math.Ceil(low)); k <= int(high); k++ {
+			peak = math.Max(peak, bins[k])
+		}
+		return peak
+	}
+
+	center := (low + high) / 2
+	i := int(center)
+	if i >= len(bins)-1 {
+		return bins[len(bins)-1]
+	}
+	frac := center - float64(i)
+	return bins[i]*(1-frac) + bins[i+1]*frac
+}
+
+// frequencyAxis 频率轴映射，将 [0, 1] 的位置映射为频率
+type frequencyAxis struct {
+	scale    FrequencyScale
+	min, max float64
+}
+
+func newFrequencyAxis(scale FrequencyScale, minFreq, maxFreq float64) frequencyAxis {
+	return frequencyAxis{scale: scale, min: minFreq, max: maxFreq}
+}
+
+// warp 将频率转换到刻度空间
+func (a frequencyAxis) warp(freq float64) float64 {
+	switch a.scale {
+	case ScaleLog:
+		return math.Log(freq)
+	case ScaleMel:
+		return HzToMel(freq, true)
+	default:
+		return freq
+	}
+}
+
+// unwarp 将刻度空间的值转换回频率
+func (a frequencyAxis) unwarp(value float64) float64 {
+	switch a.scale {
+	case ScaleLog:
+		return math.Exp(value)
+	case ScaleMel:
+		return MelToHz(value, true)
+	default:
+		return value
+	}
+}
+
+// frequency 返回位置 pos（0 为底部，1 为顶部）对应的频率
+func (a frequencyAxis) frequency(pos float64) float64 {
+	lo, hi := a.warp(a.min), a.warp(a.max)
+	return a.unwarp(lo + pos*(hi-lo))
+}
+
+// position 返回频率对应的位置
+func (a frequencyAxis) position(freq float64) float64 {
+	lo, hi := a.warp(a.min), a.warp(a.max)
+	return (a.warp(freq) - lo) / (hi - lo)
+}
+
+// drawFrequencyAxis 在左侧绘制频率刻度
+func drawFrequencyAxis(img *image.RGBA, scale frequencyAxis, top, height int) {
+	var ticks []float64
+	if scale.scale == ScaleLinear {
+		step := niceStep((scale.max - scale.min) / math.Max(1, float64(height)/minTickSpacing))
+		for f := math.Ceil(scale.min/step) * step; f <= scale.max; f += step {
+			ticks = append(ticks, f)
+		}
+	} else {
+		for _, f := range []float64{50, 100, 200, 500, 1000, 2000, 5000, 10000, 20000} {
+			if f >= scale.min && f <= scale.max {
+				ticks = append(ticks, f)
+			}
+		}
+	}
+
+	lastY := math.MaxInt32
+	for _, f := range ticks {
+		y := top + int(math.Round((1-scale.position(f))*float64(height-1)))
+		if lastY-y < glyphHeight*2 {
+			continue
+		}
+		lastY = y
+
+		fillRect(img, image.Rect(axisLeftMargin-3, y, axisLeftMargin, y+1), axisForeground)
+		label := formatFrequency(f)
+		ly := y - glyphHeight/2
+		if ly < top {
+			ly = top
+		}
+		if ly+glyphHeight > top+height {
+			ly = top + height - glyphHeight
+		}
+		drawText(img, axisLeftMargin-4-textWidth(label), ly, label, axisForeground)
+	}
+}
+
+// drawTimeAxis 在底部绘制时间刻度（秒）
+func drawTimeAxis(img *image.RGBA, left, width, y int, duration float64) {
+	if duration <= 0 {
+		return
+	}
+	step := niceStep(duration / math.Max(1, float64(width)/minTickSpacing))
+	for t := 0.0; t <= duration; t += step {
+		x := left + int(math.Round(t/duration*float64(width-1)))
+		fillRect(img, image.Rect(x, y, x+1, y+3), axisForeground)
+
+		label := strconv.FormatFloat(math.Round(t*1000)/1000, 'f', -1, 64) + "s"
+		lx := x - textWidth(label)/2
+		if lx < left {
+			lx = left
+		}
+		if lx+textWidth(label) > left+width {
+			lx = left + width - textWidth(label)
+		}
+		drawText(img, lx, y+4, label, axisForeground)
+	}
+}
+
+// niceStep 返回不小于 raw 的 1/2/5×10^n 步长
+func niceStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	exp := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*exp >= raw {
+			return m * exp
+		}
+	}
+	return 10 * exp
+}
+
+// formatFrequency 格式化频率标签，如 500、2k、1.5k
+func formatFrequency(f float64) string {
+	if f >= 1000 {
+		return strconv.FormatFloat(f/1000, 'f', -1, 64) + "k"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,221 @@
+package analysis
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// 电平比较使用的能量下限，避免对静音取对数
+const stereoEnergyFloor = 1e-12
+
+// StereoFrame 一个分析窗口内的立体声指标
+type StereoFrame struct {
+	Time        time.Duration
+	Correlation float64 // 相位相关系数，+1 为单声道，0 为不相关，-1 为反相
+	MonoLoss    float64 // 合并为单声道 (L+R)/2 后相对左右平均电平的下降量（dB，正数为下降）
+	Balance     float64 // 左右电平差（dB，正数为左声道更响）
+}
+
+// StereoReport 立体声兼容性分析结果
+type StereoReport struct {
+	Frames []StereoFrame
+
+	// 整段的指标
+	Correlation    float64
+	MinCorrelation float64 // 各窗口中最低的相关系数
+	MonoLoss       float64
+	Balance        float64
+}
+
+// AnalyzeStereo 按 window 长度的窗口分析立体声音频的相位相关、单声道兼容性和左右平衡
+func AnalyzeStereo(segment *audio.AudioSegment, window time.Duration) (*StereoReport, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+	if segment.Channels() != 2 {
+		return nil, errors.New("stereo analysis requires a stereo segment")
+	}
+	if window <= 0 {
+		return nil, errors.New("window must be positive")
+	}
+
+	samples := segment.Samples()
+	frames := len(samples) / 2
+	size := int(window.Seconds() * float64(segment.SampleRate()))
+	if size < 1 {
+		size = 1
+	}
+
+	report := &StereoReport{MinCorrelation: 1}
+	var total stereoSums
+	for start := 0; start < frames; start += size {
+		var sums stereoSums
+		for i := start; i < start+size && i < frames; i++ {
+			sums.add(samples[2*i], samples[2*i+1])
+		}
+		total.merge(sums)
+
+		frame := sums.frame()
+		frame.Time = time.Duration(float64(start) / float64(segment.SampleRate()) * float64(time.Second))
+		report.Frames = append(report.Frames, frame)
+		// 静音窗口不参与最低相关系数的统计
+		if sums.ll+sums.rr > stereoEnergyFloor && frame.Correlation < report.MinCorrelation {
+			report.MinCorrelation = frame.Correlation
+		}
+	}
+
+	overall := total.frame()
+	report.Correlation = overall.Correlation
+	report.MonoLoss = overall.MonoLoss
+	report.Balance = overall.Balance
+	return report, nil
+}
+
+// stereoSums 左右声道的能量和互能量累加
+type stereoSums struct {
+	ll, rr, lr float64
+}
+
+func (s *stereoSums) add(l, r float64) {
+	s.ll += l * l
+	s.rr += r * r
+	s.lr += l * r
+}
+
+func (s *stereoSums) merge(other stereoSums) {
+	s.ll += other.ll
+	s.rr += other.rr
+	s.lr += other.lr
+}
+
+// frame 由累加值计算立体声指标，静音时相关系数记为 1（单声道兼容）
+func (s stereoSums) frame() StereoFrame {
+	frame := StereoFrame{Correlation: 1}
+	if s.ll > 0 && s.rr > 0 {
+		frame.Correlation = s.lr / math.Sqrt(s.ll*s.rr)
+	}
+
+	// E[((L+R)/2)²] 与 (E[L²]+E[R²])/2 之比
+	stereo := (s.ll + s.rr) / 2
+	mono := (s.ll + s.rr + 2*s.lr) / 4
+	if stereo > stereoEnergyFloor {
+		frame.MonoLoss = 10 * math.Log10(stereo/math.Max(mono, stereoEnergyFloor))
+	}
+	frame.Balance = 10 * math.Log10(math.Max(s.ll, stereoEnergyFloor)/math.Max(s.rr, stereoEnergyFloor))
+	return frame
+}
+
+// GoniometerOptions 测角仪（矢量示波器）图像参数
+type GoniometerOptions struct {
+	Size       int         // 图像边长（像素），为 0 时使用 256
+	Colormap   Colormap    // 点密度的配色
+	Background color.Color // 背景色，为 nil 时为黑色
+	Axes       bool        // 是否绘制 L/R/M/S 参考线和标注
+	Meter      bool        // 是否在底部绘制相位相关表
+}
+
+// 相关表的高度
+const goniometerMeterHeight = 12
+
+// RenderGoniometer 将立体声音频渲染为测角仪图像（PNG）：竖直方向为中间信号（M），
+// 水平方向为侧边信号（S），单声道为竖线，反相为横线，只有左/右声道时为 45° 斜线
+func RenderGoniometer(segment *audio.AudioSegment, w io.Writer, options GoniometerOptions) error {
+	if segment == nil {
+		return errors.New("segment cannot be nil")
+	}
+	if segment.Channels() != 2 {
+		return errors.New("goniometer requires a stereo segment")
+	}
+	size := options.Size
+	if size <= 0 {
+		size = 256
+	}
+	background := options.Background
+	if background == nil {
+		background = color.Black
+	}
+
+	// 统计每个像素的点数
+	samples := segment.Samples()
+	half := float64(size-1) / 2
+	counts := make([]float64, size*size)
+	var peak float64
+	var sums stereoSums
+	for i := 0; i+1 < len(samples); i += 2 {
+		l, r := samples[i], samples[i+1]
+		sums.add(l, r)
+		// 旋转 45°：x = S = (R-L)/2，y = M = (L+R)/2，满幅的单声道（L=R=±1）和反相信号落在边缘，
+		// 超出满幅的样本限制在边框上
+		x := (r - l) / 2
+		y := (l + r) / 2
+		px := min(max(int(math.Round(half+x*half)), 0), size-1)
+		py := min(max(int(math.Round(half-y*half)), 0), size-1)
+		counts[py*size+px]++
+		peak = math.Max(peak, counts[py*size+px])
+	}
+
+	height := size
+	if options.Meter {
+		height += goniometerMeterHeight
+	}
+	img := image.NewRGBA(image.Rect(0, 0, size, height))
+	fillRect(img, img.Bounds(), background)
+
+	guide := color.RGBA{R: 90, G: 90, B: 90, A: 255}
+	if options.Axes {
+		drawGoniometerAxes(img, size, guide)
+	}
+
+	// 按对数密度着色
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		img.Set(i%size, i/size, options.Colormap.Color(0.25+0.75*math.Log1p(c)/math.Log1p(peak)))
+	}
+
+	if options.Meter {
+		drawCorrelationMeter(img, size, sums.frame().Correlation, guide)
+	}
+
+	return png.Encode(w, img)
+}
+
+// drawGoniometerAxes 绘制竖直（M）、水平（S）和两条对角线（L、R）参考线
+func drawGoniometerAxes(img *image.RGBA, size int, c color.Color) {
+	mid := size / 2
+	for i := 0; i < size; i++ {
+		img.Set(mid, i, c)
+		img.Set(i, mid, c)
+		img.Set(i, i, c)
+		img.Set(size-1-i, i, c)
+	}
+	margin := 3
+	drawText(img, mid+margin, margin, "M", c)
+	drawText(img, size-margin-textWidth("S"), mid+margin, "S", c)
+	drawText(img, margin+glyphWidth+2, margin, "L", c)
+	drawText(img, size-margin-2*glyphWidth-2, margin, "R", c)
+}
+
+// drawCorrelationMeter 在图像底部绘制 -1~+1 的相位相关表
+func drawCorrelationMeter(img *image.RGBA, size int, correlation float64, c color.Color) {
+	top := size + 2
+	bottom := size + goniometerMeterHeight - 2
+	fillRect(img, image.Rect(0, top+(bottom-top)/2, size, top+(bottom-top)/2+1), c)
+	fillRect(img, image.Rect(size/2, top, size/2+1, bottom), c)
+
+	// 正相关为绿色，负相关为红色
+	marker := color.RGBA{R: 80, G: 200, B: 80, A: 255}
+	if correlation < 0 {
+		marker = color.RGBA{R: 220, G: 60, B: 60, A: 255}
+	}
+	x := int(math.Round((correlation + 1) / 2 * float64(size-1)))
+	fillRect(img, image.Rect(x-1, top, x+2, bottom), marker)
+}
@@ -0,0 +1,222 @@
+package analysis
+
+import (
+	"math/cmplx"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// STFTOptions 短时傅里叶变换参数
+type STFTOptions struct {
+	FrameSize int        // 帧长（样本数），为 0 时使用 2048
+	HopSize   int        // 帧移（样本数），为 0 时使用 FrameSize/4
+	Window    WindowFunc // 窗函数，为 nil 时使用 Hann 窗
+	Center    bool       // 是否在两端各补 FrameSize/2 个零，使第 i 帧以 i*HopSize 为中心
+}
+
+// DefaultSTFTOptions 返回默认参数（与 librosa 默认值一致）
+func DefaultSTFTOptions() STFTOptions {
+	return STFTOptions{
+		FrameSize: 2048,
+		HopSize:   512,
+		Window:    Hann,
+		Center:    true,
+	}
+}
+
+// withDefaults 填充未设置的参数
+func (o STFTOptions) withDefaults() STFTOptions {
+	if o.FrameSize <= 0 {
+		o.FrameSize = 2048
+	}
+	if o.HopSize <= 0 {
+		o.HopSize = o.FrameSize / 4
+	}
+	if o.Window == nil {
+		o.Window = Hann
+	}
+	return o
+}
+
+// Spectrogram 单声道的短时频谱，Magnitude 与 Phase 按 [帧][频点] 存储
+type Spectrogram struct {
+	Magnitude [][]float64
+	Phase     [][]float64
+
+	SampleRate int
+	FrameSize  int
+	HopSize    int
+	Center     bool
+	// 原始信号的样本数，用于逆变换时截取
+	Length int
+
+	window []float64
+}
+
+// Frames 返回帧数
+func (s *Spectrogram) Frames() int {
+	return len(s.Magnitude)
+}
+
+// Bins 返回每帧的频点数（FrameSize/2+1）
+func (s *Spectrogram) Bins() int {
+	return s.FrameSize/2 + 1
+}
+
+// BinFrequency 返回第 k 个频点的中心频率（Hz）
+func (s *Spectrogram) BinFrequency(k int) float64 {
+	return float64(k) * float64(s.SampleRate) / float64(s.FrameSize)
+}
+
+// FrameTime 返回第 i 帧的时间位置（开启 Center 时为帧中心，否则为帧起点）
+func (s *Spectrogram) FrameTime(i int) time.Duration {
+	return time.Duration(float64(i*s.HopSize) / float64(s.SampleRate) * float64(time.Second))
+}
+
+// Power 返回功率谱（幅度平方）
+func (s *Spectrogram) Power() [][]float64 {
+	power := make([][]float64, len(s.Magnitude))
+	for i, frame := range s.Magnitude {
+		power[i] = make([]float64, len(frame))
+		for k, mag := range frame {
+			power[i][k] = mag * mag
+		}
+	}
+	return power
+}
+
+// STFT 对音频段的每个声道分别计算短时傅里叶变换
+func STFT(segment *audio.AudioSegment, options STFTOptions) ([]*Spectrogram, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+
+	data := segment.SplitChannels()
+	specs := make([]*Spectrogram, len(data))
+	for ch, samples := range data {
+		spec, err := STFTSamples(samples, segment.SampleRate(), options)
+		if err != nil {
+			return nil, err
+		}
+		specs[ch] = spec
+	}
+	return specs, nil
+}
+
+// STFTSamples 对单声道样本计算短时傅里叶变换
+func STFTSamples(samples []float64, sampleRate int, options STFTOptions) (*Spectrogram, error) {
+	options = options.withDefaults()
+	if options.HopSize > options.FrameSize {
+		return nil, errors.New("hop size must not exceed frame size")
+	}
+	if sampleRate <= 0 {
+		return nil, errors.New("sample rate must be positive")
+	}
+
+	size := options.FrameSize
+	window := options.Window(size)
+
+	padded := samples
+	if options.Center {
+		padded = make([]float64, len(samples)+size)
+		copy(padded[size/2:], samples)
+	}
+
+	// 居中模式与 librosa 一致取整帧；否则补齐最后一帧，保证所有样本都被覆盖
+	frames := 1
+	if len(padded) > size {
+		if options.Center {
+			frames = 1 + (len(padded)-size)/options.HopSize
+		} else {
+			frames = 1 + (len(padded)-size+options.HopSize-1)/options.HopSize
+		}
+	}
+
+	spec := &Spectrogram{
+		Magnitude:  make([][]float64, frames),
+		Phase:      make([][]float64, frames),
+		SampleRate: sampleRate,
+		FrameSize:  size,
+		HopSize:    options.HopSize,
+		Center:     options.Center,
+		Length:     len(samples),
+		window:     window,
+	}
+
+	frame := make([]float64, size)
+	for i := 0; i < frames; i++ {
+		start := i * options.HopSize
+		for j := range frame {
+			var v float64
+			if start+j < len(padded) {
+				v = padded[start+j]
+			}
+			frame[j] = v * window[j]
+		}
+
+		bins := RFFT(frame)
+		spec.Magnitude[i] = make([]float64, len(bins))
+		spec.Phase[i] = make([]float64, len(bins))
+		for k, bin := range bins {
+			spec.Magnitude[i][k] = cmplx.Abs(bin)
+			spec.Phase[i][k] = cmplx.Phase(bin)
+		}
+	}
+
+	return spec, nil
+}
+
+// ISTFTSamples 通过加权重叠相加由幅度和相位重建单声道样本
+func ISTFTSamples(spec *Spectrogram) []float64 {
+	size := spec.FrameSize
+	window := spec.window
+	if len(window) != size {
+		window = Hann(size)
+	}
+
+	total := (spec.Frames()-1)*spec.HopSize + size
+	output := make([]float64, total)
+	norm := make([]float64, total)
+
+	bins := make([]complex128, size/2+1)
+	for i := range spec.Magnitude {
+		for k := range bins {
+			bins[k] = cmplx.Rect(spec.Magnitude[i][k], spec.Phase[i][k])
+		}
+		frame := IRFFT(bins, size)
+
+		start := i * spec.HopSize
+		for j, v := range frame {
+			output[start+j] += v * window[j]
+			norm[start+j] += window[j] * window[j]
+		}
+	}
+
+	offset := 0
+	if spec.Center {
+		offset = size / 2
+	}
+	result := make([]float64, spec.Length)
+	for i := range result {
+		pos := offset + i
+		if pos < total && norm[pos] > 1e-10 {
+			result[i] = output[pos] / norm[pos]
+		}
+	}
+	return result
+}
+
+// ISTFT 由各声道的短时频谱重建音频段
+func ISTFT(specs []*Spectrogram, bitDepth int) (*audio.AudioSegment, error) {
+	if len(specs) == 0 {
+		return nil, errors.New("no spectrogram provided")
+	}
+
+	data := make([][]float64, len(specs))
+	for ch, spec := range specs {
+		data[ch] = ISTFTSamples(spec)
+	}
+	return audio.NewAudioSegmentFromChannels(data, specs[0].SampleRate, bitDepth)
+}
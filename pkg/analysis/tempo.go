@@ -0,0 +1,144 @@
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+const (
+	// 速度搜索范围和先验分布（对数高斯，中心 120 BPM，与 librosa 一致）
+	tempoMinBPM   = 30.0
+	tempoMaxBPM   = 300.0
+	tempoPriorBPM = 120.0
+	tempoPriorStd = 1.0 // 以八度为单位
+	beatTightness = 100.0
+)
+
+// Tempo 速度估计结果
+type Tempo struct {
+	BPM        float64         // 每分钟节拍数
+	Confidence float64         // 最佳周期处的归一化自相关值
+	Beats      []time.Duration // 节拍网格位置
+}
+
+// EstimateTempo 基于谱通量包络的自相关估计速度，并用动态规划跟踪节拍位置
+func EstimateTempo(segment *audio.AudioSegment) (*Tempo, error) {
+	envelope, err := OnsetStrength(segment, OnsetSpectralFlux)
+	if err != nil {
+		return nil, err
+	}
+
+	frameRate := envelope.FrameRate()
+	strength := envelope.Strength
+	minLag := int(math.Floor(60 * frameRate / tempoMaxBPM))
+	maxLag := int(math.Ceil(60 * frameRate / tempoMinBPM))
+	if minLag < 1 {
+		minLag = 1
+	}
+	if maxLag >= len(strength) {
+		maxLag = len(strength) - 1
+	}
+	if maxLag <= minLag {
+		return nil, errors.New("segment is too short to estimate tempo")
+	}
+
+	// 去均值后的自相关
+	var mean float64
+	for _, v := range strength {
+		mean += v
+	}
+	mean /= float64(len(strength))
+	centered := make([]float64, len(strength))
+	for i, v := range strength {
+		centered[i] = v - mean
+	}
+
+	acf := make([]float64, maxLag+2)
+	for lag := 0; lag < len(acf) && lag < len(centered); lag++ {
+		for i := lag; i < len(centered); i++ {
+			acf[lag] += centered[i] * centered[i-lag]
+		}
+	}
+	if acf[0] <= 0 {
+		return nil, errors.New("no rhythmic content found")
+	}
+
+	best := -1
+	var bestScore float64
+	for lag := minLag; lag <= maxLag; lag++ {
+		bpm := 60 * frameRate / float64(lag)
+		prior := math.Exp(-0.5 * math.Pow(math.Log2(bpm/tempoPriorBPM)/tempoPriorStd, 2))
+		if score := acf[lag] / acf[0] * prior; best < 0 || score > bestScore {
+			best, bestScore = lag, score
+		}
+	}
+
+	// 抛物线插值
+	period := float64(best)
+	if best > 0 && best+1 < len(acf) {
+		y0, y1, y2 := acf[best-1], acf[best], acf[best+1]
+		if denom := y0 - 2*y1 + y2; denom != 0 {
+			period += 0.5 * (y0 - y2) / denom
+		}
+	}
+
+	tempo := &Tempo{
+		BPM:        60 * frameRate / period,
+		Confidence: math.Max(0, acf[best]/acf[0]),
+	}
+	for _, frame := range trackBeats(strength, period) {
+		tempo.Beats = append(tempo.Beats, envelope.FrameTime(frame))
+	}
+	return tempo, nil
+}
+
+// trackBeats 动态规划节拍跟踪（Ellis 2007）：在起始点强度与节拍间隔一致性之间取最优
+func trackBeats(strength []float64, period float64) []int {
+	n := len(strength)
+	if n == 0 || period <= 0 {
+		return nil
+	}
+
+	score := make([]float64, n)
+	backlink := make([]int, n)
+	for t := 0; t < n; t++ {
+		backlink[t] = -1
+		best := math.Inf(-1)
+		from := t - int(math.Round(2*period))
+		to := t - int(math.Round(period/2))
+		for prev := from; prev <= to; prev++ {
+			if prev < 0 {
+				continue
+			}
+			penalty := math.Log(float64(t-prev) / period)
+			if candidate := score[prev] - beatTightness*penalty*penalty; candidate > best {
+				best = candidate
+				backlink[t] = prev
+			}
+		}
+		score[t] = strength[t]
+		if backlink[t] >= 0 {
+			score[t] += best
+		}
+	}
+
+	// 从末尾一个周期内得分最高的帧开始回溯
+	end := n - 1
+	for t := n - 1; t >= 0 && float64(n-1-t) < period; t-- {
+		if score[t] > score[end] {
+			end = t
+		}
+	}
+
+	var beats []int
+	for t := end; t >= 0; t = backlink[t] {
+		beats = append(beats, t)
+	}
+	for i, j := 0, len(beats)-1; i < j; i, j = i+1, j-1 {
+		beats[i], beats[j] = beats[j], beats[i]
+	}
+	return beats
+}
@@ -0,0 +1,22 @@
+package analysis
+
+import "github.com/HiChen85/godub/pkg/audio"
+
+// monoSamples 将音频段混合为单声道（各声道取平均）
+func monoSamples(segment *audio.AudioSegment) []float64 {
+	channels := segment.Channels()
+	samples := segment.Samples()
+	if channels == 1 {
+		return samples
+	}
+
+	mono := make([]float64, len(samples)/channels)
+	for i := range mono {
+		var sum float64
+		for ch := 0; ch < channels; ch++ {
+			sum += samples[i*channels+ch]
+		}
+		mono[i] = sum / float64(channels)
+	}
+	return mono
+}
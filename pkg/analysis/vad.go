@@ -0,0 +1,367 @@
+package analysis
+
+import (
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/HiChen85/godub/pkg/stream"
+	"github.com/pkg/errors"
+)
+
+// VADMode 语音检测的激进程度，与 WebRTC VAD 的 0~3 档对应，越激进越不容易把噪声判为语音
+type VADMode int
+
+const (
+	// VADQuality 尽量不漏掉语音
+	VADQuality VADMode = iota
+	// VADLowBitrate 较保守
+	VADLowBitrate
+	// VADAggressive 激进
+	VADAggressive
+	// VADVeryAggressive 非常激进，只保留明确的语音
+	VADVeryAggressive
+)
+
+// vadThresholds 每种模式的判决门限
+type vadThresholds struct {
+	snr       float64 // 相对噪声底的最小信噪比（dB）
+	bandRatio float64 // 语音频带能量占比下限
+	flatness  float64 // 频谱平坦度上限
+}
+
+var vadModeThresholds = map[VADMode]vadThresholds{
+	VADQuality:        {snr: 6, bandRatio: 0.3, flatness: 0.6},
+	VADLowBitrate:     {snr: 9, bandRatio: 0.4, flatness: 0.5},
+	VADAggressive:     {snr: 12, bandRatio: 0.5, flatness: 0.4},
+	VADVeryAggressive: {snr: 15, bandRatio: 0.6, flatness: 0.3},
+}
+
+const (
+	// 语音频带
+	vadBandLow  = 80.0
+	vadBandHigh = 4000.0
+	// 低于该电平（dBFS）的帧一律视为非语音，噪声底也不会低于该值
+	vadMinEnergy = -60.0
+	// 噪声底在非语音帧和语音帧上的跟踪速度
+	vadNoiseAdapt  = 0.1
+	vadSpeechAdapt = 0.005
+)
+
+// VADOptions 语音检测参数
+type VADOptions struct {
+	Mode          VADMode
+	FrameDuration time.Duration // 帧长，10/20/30ms
+	Hangover      time.Duration // 语音结束后继续判为语音的时长
+	Padding       time.Duration // 语音区间前后扩展的时长
+	MinSpeech     time.Duration // 短于该时长的语音区间被丢弃
+}
+
+// DefaultVADOptions 返回默认语音检测参数
+func DefaultVADOptions() VADOptions {
+	return VADOptions{
+		Mode:          VADLowBitrate,
+		FrameDuration: 30 * time.Millisecond,
+		Hangover:      150 * time.Millisecond,
+		Padding:       100 * time.Millisecond,
+		MinSpeech:     100 * time.Millisecond,
+	}
+}
+
+// VADFrame 一帧的检测结果
+type VADFrame struct {
+	Start  time.Duration
+	End    time.Duration
+	Speech bool
+	Energy float64 // 帧电平（dBFS）
+	SNR    float64 // 相对噪声底的信噪比（dB）
+}
+
+// SpeechRegion 合并后的语音区间
+type SpeechRegion struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Duration 返回区间时长
+func (r SpeechRegion) Duration() time.Duration {
+	return r.End - r.Start
+}
+
+// VAD 流式语音检测器，可逐块输入交错样本
+type VAD struct {
+	sampleRate int
+	channels   int
+	frameSize  int
+	fftSize    int
+	window     []float64
+	thresholds vadThresholds
+
+	hangoverFrames int
+	hangover       int // 剩余的拖尾帧数
+
+	noiseFloor  float64
+	initialized bool
+
+	pending []float64 // 未凑满一帧的单声道样本
+	partial []float64 // 未凑满一个采样帧的交错样本
+	frames  int       // 已输出的帧数
+}
+
+// NewVAD 创建语音检测器
+func NewVAD(sampleRate, channels int, options VADOptions) (*VAD, error) {
+	if sampleRate <= 0 || channels <= 0 {
+		return nil, errors.New("invalid sample rate or channel count")
+	}
+	thresholds, ok := vadModeThresholds[options.Mode]
+	if !ok {
+		return nil, errors.New("unsupported VAD mode")
+	}
+	switch options.FrameDuration {
+	case 10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond:
+	default:
+		return nil, errors.New("frame duration must be 10, 20 or 30ms")
+	}
+	if options.Hangover < 0 {
+		return nil, errors.New("hangover cannot be negative")
+	}
+
+	frameSize := int(options.FrameDuration.Seconds() * float64(sampleRate))
+	if frameSize < 2 {
+		return nil, errors.New("sample rate too low for VAD")
+	}
+
+	return &VAD{
+		sampleRate:     sampleRate,
+		channels:       channels,
+		frameSize:      frameSize,
+		fftSize:        NextPowerOfTwo(frameSize),
+		window:         Hann(frameSize),
+		thresholds:     thresholds,
+		hangoverFrames: int(math.Ceil(float64(options.Hangover) / float64(options.FrameDuration))),
+	}, nil
+}
+
+// Process 输入一块交错样本，返回这块数据凑满的所有帧的检测结果
+func (v *VAD) Process(samples []float64) []VADFrame {
+	// 先把交错样本混合为单声道，跨块的不完整采样帧留到下次
+	v.partial = append(v.partial, samples...)
+	complete := len(v.partial) / v.channels * v.channels
+	for i := 0; i < complete; i += v.channels {
+		var sum float64
+		for ch := 0; ch < v.channels; ch++ {
+			sum += v.partial[i+ch]
+		}
+		v.pending = append(v.pending, sum/float64(v.channels))
+	}
+	v.partial = append(v.partial[:0], v.partial[complete:]...)
+
+	var frames []VADFrame
+	offset := 0
+	for ; offset+v.frameSize <= len(v.pending); offset += v.frameSize {
+		frames = append(frames, v.classify(v.pending[offset:offset+v.frameSize]))
+	}
+	v.pending = append(v.pending[:0], v.pending[offset:]...)
+	return frames
+}
+
+// classify 判决一帧并更新噪声底和拖尾状态
+func (v *VAD) classify(frame []float64) VADFrame {
+	energy, bandRatio, flatness := v.features(frame)
+
+	if !v.initialized {
+		v.noiseFloor = math.Max(energy, vadMinEnergy)
+		v.initialized = true
+	}
+	snr := energy - v.noiseFloor
+
+	speech := energy > vadMinEnergy &&
+		snr >= v.thresholds.snr &&
+		bandRatio >= v.thresholds.bandRatio &&
+		flatness <= v.thresholds.flatness
+
+	// 噪声底：遇到更安静的帧立即下降，否则缓慢上升（语音帧上更慢）
+	switch {
+	case energy < v.noiseFloor:
+		v.noiseFloor = math.Max(energy, vadMinEnergy)
+	case speech:
+		v.noiseFloor += vadSpeechAdapt * (energy - v.noiseFloor)
+	default:
+		v.noiseFloor += vadNoiseAdapt * (energy - v.noiseFloor)
+	}
+
+	// 拖尾平滑：语音结束后的若干帧仍判为语音
+	if speech {
+		v.hangover = v.hangoverFrames
+	} else if v.hangover > 0 {
+		v.hangover--
+		speech = true
+	}
+
+	start := v.frameTime(v.frames)
+	v.frames++
+	return VADFrame{
+		Start:  start,
+		End:    v.frameTime(v.frames),
+		Speech: speech,
+		Energy: energy,
+		SNR:    snr,
+	}
+}
+
+// features 计算帧电平（dBFS）、语音频带能量占比和频谱平坦度
+func (v *VAD) features(frame []float64) (energy, bandRatio, flatness float64) {
+	var mean float64
+	for _, s := range frame {
+		mean += s
+	}
+	mean /= float64(len(frame))
+
+	buffer := make([]float64, v.fftSize)
+	var power float64
+	for i, s := range frame {
+		s -= mean
+		power += s * s
+		buffer[i] = s * v.window[i]
+	}
+	energy = 10 * math.Log10(power/float64(len(frame))+1e-12)
+
+	spectrum := RFFT(buffer)
+	binHz := float64(v.sampleRate) / float64(v.fftSize)
+	var total, band, logSum float64
+	for k, c := range spectrum {
+		p := real(c)*real(c) + imag(c)*imag(c)
+		total += p
+		if f := float64(k) * binHz; f >= vadBandLow && f <= vadBandHigh {
+			band += p
+		}
+		logSum += math.Log(p + 1e-12)
+	}
+	if total <= 0 {
+		return energy, 0, 1
+	}
+	n := float64(len(spectrum))
+	flatness = math.Exp(logSum/n) / (total/n + 1e-12)
+	return energy, band / total, flatness
+}
+
+// frameTime 返回第 i 帧的起始时间
+func (v *VAD) frameTime(i int) time.Duration {
+	return time.Duration(float64(i*v.frameSize) / float64(v.sampleRate) * float64(time.Second))
+}
+
+// VoiceActivity 对音频段逐帧检测语音，末尾不足一帧的样本被忽略
+func VoiceActivity(segment *audio.AudioSegment, options VADOptions) ([]VADFrame, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+
+	vad, err := NewVAD(segment.SampleRate(), 1, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// 离线处理时整段可见，用帧电平的低分位数预估噪声底，避免开头就是语音时误判
+	mono := monoSamples(segment)
+	if energies := frameEnergies(mono, vad.frameSize); len(energies) > 0 {
+		sort.Float64s(energies)
+		vad.noiseFloor = math.Max(energies[len(energies)/10], vadMinEnergy)
+		vad.initialized = true
+	}
+	return vad.Process(mono), nil
+}
+
+// VoiceActivityFromStream 以流式方式对音频流逐帧检测语音
+func VoiceActivityFromStream(s *stream.AudioStream, options VADOptions) ([]VADFrame, error) {
+	if s == nil {
+		return nil, errors.New("stream cannot be nil")
+	}
+
+	vad, err := NewVAD(s.SampleRate(), s.Channels(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames []VADFrame
+	buffer := make([]float64, s.BufferSize())
+	for {
+		n, err := s.Read(buffer)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, vad.Process(buffer[:n])...)
+	}
+	return frames, nil
+}
+
+// SpeechRegions 将逐帧结果合并为语音区间：丢弃过短的区间，前后扩展 Padding 后合并重叠部分
+func SpeechRegions(frames []VADFrame, options VADOptions) []SpeechRegion {
+	var raw []SpeechRegion
+	for _, frame := range frames {
+		if !frame.Speech {
+			continue
+		}
+		if n := len(raw); n > 0 && raw[n-1].End == frame.Start {
+			raw[n-1].End = frame.End
+		} else {
+			raw = append(raw, SpeechRegion{Start: frame.Start, End: frame.End})
+		}
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	end := frames[len(frames)-1].End
+
+	var regions []SpeechRegion
+	for _, region := range raw {
+		if region.Duration() < options.MinSpeech {
+			continue
+		}
+		region.Start -= options.Padding
+		if region.Start < 0 {
+			region.Start = 0
+		}
+		region.End += options.Padding
+		if region.End > end {
+			region.End = end
+		}
+
+		if n := len(regions); n > 0 && region.Start <= regions[n-1].End {
+			regions[n-1].End = region.End
+			continue
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// DetectSpeech 检测音频段中的语音区间
+func DetectSpeech(segment *audio.AudioSegment, options VADOptions) ([]SpeechRegion, error) {
+	frames, err := VoiceActivity(segment, options)
+	if err != nil {
+		return nil, err
+	}
+	return SpeechRegions(frames, options), nil
+}
+
+// frameEnergies 计算各不重叠帧的电平（dBFS）
+func frameEnergies(samples []float64, frameSize int) []float64 {
+	energies := make([]float64, 0, len(samples)/frameSize)
+	for start := 0; start+frameSize <= len(samples); start += frameSize {
+		var mean, power float64
+		for _, s := range samples[start : start+frameSize] {
+			mean += s
+		}
+		mean /= float64(frameSize)
+		for _, s := range samples[start : start+frameSize] {
+			power += (s - mean) * (s - mean)
+		}
+		energies = append(energies, 10*math.Log10(power/float64(frameSize)+1e-12))
+	}
+	return energies
+}
@@ -0,0 +1,315 @@
+package analysis
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/HiChen85/godub/pkg/stream"
+	"github.com/pkg/errors"
+)
+
+// Waveform 波形峰值数据，每个像素保存每个声道的最小值和最大值，
+// 与 BBC audiowaveform 的 JSON / .dat 格式兼容
+type Waveform struct {
+	SampleRate      int
+	SamplesPerPixel int
+	Bits            int // 8 或 16
+	Channels        int
+	Min             [][]int // [声道][像素]
+	Max             [][]int // [声道][像素]
+}
+
+// Length 返回像素数
+func (w *Waveform) Length() int {
+	if len(w.Min) == 0 {
+		return 0
+	}
+	return len(w.Min[0])
+}
+
+// SamplesPerPixelFor 根据每秒像素数计算每像素样本数
+func SamplesPerPixelFor(sampleRate int, pixelsPerSecond float64) int {
+	if pixelsPerSecond <= 0 {
+		return sampleRate
+	}
+	spp := int(math.Round(float64(sampleRate) / pixelsPerSecond))
+	if spp < 1 {
+		spp = 1
+	}
+	return spp
+}
+
+// WaveformPeaks 计算音频段的波形峰值，samplesPerPixel 为每个像素对应的帧数，bits 为 8 或 16
+func WaveformPeaks(segment *audio.AudioSegment, samplesPerPixel, bits int) (*Waveform, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+
+	builder, err := newWaveformBuilder(segment.SampleRate(), segment.Channels(), samplesPerPixel, bits)
+	if err != nil {
+		return nil, err
+	}
+	builder.add(segment.Samples())
+	return builder.finish(), nil
+}
+
+// WaveformPeaksFromStream 以流式方式从音频流计算波形峰值，无需将整个文件载入内存
+func WaveformPeaksFromStream(s *stream.AudioStream, samplesPerPixel, bits int) (*Waveform, error) {
+	if s == nil {
+		return nil, errors.New("stream cannot be nil")
+	}
+
+	builder, err := newWaveformBuilder(s.SampleRate(), s.Channels(), samplesPerPixel, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := make([]float64, s.BufferSize())
+	for {
+		n, err := s.Read(buffer)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		builder.add(buffer[:n])
+	}
+	return builder.finish(), nil
+}
+
+// waveformBuilder 增量累积每个像素的最小/最大值
+type waveformBuilder struct {
+	waveform *Waveform
+	scale    float64
+
+	channel  int // 下一个样本所属声道
+	frames   int // 当前像素已累积的帧数
+	min, max []int
+}
+
+func newWaveformBuilder(sampleRate, channels, samplesPerPixel, bits int) (*waveformBuilder, error) {
+	if samplesPerPixel <= 0 {
+		return nil, errors.New("samples per pixel must be positive")
+	}
+	if bits != 8 && bits != 16 {
+		return nil, errors.New("bits must be 8 or 16")
+	}
+
+	return &waveformBuilder{
+		waveform: &Waveform{
+			SampleRate:      sampleRate,
+			SamplesPerPixel: samplesPerPixel,
+			Bits:            bits,
+			Channels:        channels,
+			Min:             make([][]int, channels),
+			Max:             make([][]int, channels),
+		},
+		scale: math.Ldexp(1, bits-1),
+		min:   make([]int, channels),
+		max:   make([]int, channels),
+	}, nil
+}
+
+// add 追加交错样本，可以在任意位置分块
+func (b *waveformBuilder) add(samples []float64) {
+	limit := int(b.scale)
+	for _, sample := range samples {
+		value := int(math.Floor(sample * b.scale))
+		if value >= limit {
+			value = limit - 1
+		} else if value < -limit {
+			value = -limit
+		}
+
+		ch := b.channel
+		if b.frames == 0 || value < b.min[ch] {
+			b.min[ch] = value
+		}
+		if b.frames == 0 || value > b.max[ch] {
+			b.max[ch] = value
+		}
+
+		b.channel++
+		if b.channel == b.waveform.Channels {
+			b.channel = 0
+			b.frames++
+			if b.frames == b.waveform.SamplesPerPixel {
+				b.flush()
+			}
+		}
+	}
+}
+
+// flush 结束当前像素
+func (b *waveformBuilder) flush() {
+	for ch := range b.min {
+		b.waveform.Min[ch] = append(b.waveform.Min[ch], b.min[ch])
+		b.waveform.Max[ch] = append(b.waveform.Max[ch], b.max[ch])
+	}
+	b.frames = 0
+}
+
+// finish 输出最后一个不完整的像素并返回结果
+func (b *waveformBuilder) finish() *Waveform {
+	if b.frames > 0 {
+		b.flush()
+	}
+	return b.waveform
+}
+
+// waveformJSON audiowaveform JSON 格式（version 2）
+type waveformJSON struct {
+	Version         int   `json:"version"`
+	Channels        int   `json:"channels"`
+	SampleRate      int   `json:"sample_rate"`
+	SamplesPerPixel int   `json:"samples_per_pixel"`
+	Bits            int   `json:"bits"`
+	Length          int   `json:"length"`
+	Data            []int `json:"data"`
+}
+
+// interleaved 按 像素 → 声道 → (min, max) 的顺序展开数据
+func (w *Waveform) interleaved() []int {
+	data := make([]int, 0, w.Length()*w.Channels*2)
+	for i := 0; i < w.Length(); i++ {
+		for ch := 0; ch < w.Channels; ch++ {
+			data = append(data, w.Min[ch][i], w.Max[ch][i])
+		}
+	}
+	return data
+}
+
+// WriteJSON 以 audiowaveform JSON 格式写出
+func (w *Waveform) WriteJSON(out io.Writer) error {
+	return json.NewEncoder(out).Encode(waveformJSON{
+		Version:         2,
+		Channels:        w.Channels,
+		SampleRate:      w.SampleRate,
+		SamplesPerPixel: w.SamplesPerPixel,
+		Bits:            w.Bits,
+		Length:          w.Length(),
+		Data:            w.interleaved(),
+	})
+}
+
+// waveformDatHeader audiowaveform .dat 文件头（version 2）
+type waveformDatHeader struct {
+	Version         int32
+	Flags           uint32 // bit 0: 0 表示 16 位，1 表示 8 位
+	SampleRate      int32
+	SamplesPerPixel int32
+	Length          uint32
+	Channels        int32
+}
+
+// WriteDat 以 audiowaveform 二进制 .dat 格式写出
+func (w *Waveform) WriteDat(out io.Writer) error {
+	header := waveformDatHeader{
+		Version:         2,
+		SampleRate:      int32(w.SampleRate),
+		SamplesPerPixel: int32(w.SamplesPerPixel),
+		Length:          uint32(w.Length()),
+		Channels:        int32(w.Channels),
+	}
+	if w.Bits == 8 {
+		header.Flags = 1
+	}
+	if err := binary.Write(out, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+
+	data := w.interleaved()
+	if w.Bits == 8 {
+		buf := make([]int8, len(data))
+		for i, v := range data {
+			buf[i] = int8(v)
+		}
+		return binary.Write(out, binary.LittleEndian, buf)
+	}
+	buf := make([]int16, len(data))
+	for i, v := range data {
+		buf[i] = int16(v)
+	}
+	return binary.Write(out, binary.LittleEndian, buf)
+}
+
+// WaveformImageOptions 波形图渲染参数
+type WaveformImageOptions struct {
+	Width      int         // 图像宽度（像素），为 0 时等于波形像素数
+	Height     int         // 每个声道的高度（像素），为 0 时使用 128
+	Background color.Color // 背景色，为 nil 时为白色
+	Foreground color.Color // 波形颜色，为 nil 时为深蓝色
+	Axis       color.Color // 零线颜色，为 nil 时为浅灰色
+}
+
+// RenderWaveform 将波形峰值渲染为PNG，多个声道自上而下堆叠
+func RenderWaveform(waveform *Waveform, out io.Writer, options WaveformImageOptions) error {
+	length := waveform.Length()
+	if length == 0 {
+		return errors.New("waveform is empty")
+	}
+
+	width := options.Width
+	if width <= 0 {
+		width = length
+	}
+	height := options.Height
+	if height <= 0 {
+		height = 128
+	}
+	background := options.Background
+	if background == nil {
+		background = color.White
+	}
+	foreground := options.Foreground
+	if foreground == nil {
+		foreground = color.RGBA{R: 35, G: 70, B: 140, A: 255}
+	}
+	axis := options.Axis
+	if axis == nil {
+		axis = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	}
+
+	channels := waveform.Channels
+	img := image.NewRGBA(image.Rect(0, 0, width, channels*height+(channels-1)*channelSeparator))
+	fillRect(img, img.Bounds(), background)
+
+	scale := math.Ldexp(1, waveform.Bits-1)
+	for ch := 0; ch < channels; ch++ {
+		top := ch * (height + channelSeparator)
+		center := top + height/2
+		fillRect(img, image.Rect(0, center, width, center+1), axis)
+
+		toY := func(v int) int {
+			return top + int(math.Round((1-(float64(v)/scale+1)/2)*float64(height-1)))
+		}
+
+		for x := 0; x < width; x++ {
+			// 多个像素映射到同一列时合并峰值
+			from := x * length / width
+			to := (x + 1) * length / width
+			if to <= from {
+				to = from + 1
+			}
+			lo, hi := waveform.Min[ch][from], waveform.Max[ch][from]
+			for i := from + 1; i < to && i < length; i++ {
+				if waveform.Min[ch][i] < lo {
+					lo = waveform.Min[ch][i]
+				}
+				if waveform.Max[ch][i] > hi {
+					hi = waveform.Max[ch][i]
+				}
+			}
+			fillRect(img, image.Rect(x, toY(hi), x+1, toY(lo)+1), foreground)
+		}
+	}
+
+	return png.Encode(out, img)
+}
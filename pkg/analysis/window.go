@@ -0,0 +1,69 @@
+package analysis
+
+import "math"
+
+// WindowFunc 生成指定长度的窗函数
+type WindowFunc func(size int) []float64
+
+// Rectangular 矩形窗
+func Rectangular(size int) []float64 {
+	window := make([]float64, size)
+	for i := range window {
+		window[i] = 1
+	}
+	return window
+}
+
+// Hann 周期Hann窗（适用于STFT）
+func Hann(size int) []float64 {
+	return cosineWindow(size, 0.5, 0.5, 0)
+}
+
+// Hamming 周期Hamming窗
+func Hamming(size int) []float64 {
+	return cosineWindow(size, 0.54, 0.46, 0)
+}
+
+// Blackman 周期Blackman窗
+func Blackman(size int) []float64 {
+	return cosineWindow(size, 0.42, 0.5, 0.08)
+}
+
+// Kaiser 返回参数为 beta 的Kaiser窗函数，beta 越大旁瓣越低、主瓣越宽
+func Kaiser(beta float64) WindowFunc {
+	return func(size int) []float64 {
+		window := make([]float64, size)
+		denom := besselI0(beta)
+		for i := range window {
+			// 周期窗：按 size+1 点对称窗取前 size 点
+			r := 2*float64(i)/float64(size) - 1
+			window[i] = besselI0(beta*math.Sqrt(1-r*r)) / denom
+		}
+		return window
+	}
+}
+
+// cosineWindow 生成广义余弦窗 a0 - a1·cos(2πn/N) + a2·cos(4πn/N)
+func cosineWindow(size int, a0, a1, a2 float64) []float64 {
+	window := make([]float64, size)
+	for i := range window {
+		phase := 2 * math.Pi * float64(i) / float64(size)
+		window[i] = a0 - a1*math.Cos(phase) + a2*math.Cos(2*phase)
+	}
+	return window
+}
+
+// besselI0 第一类零阶修正贝塞尔函数（级数展开）
+func besselI0(x float64) float64 {
+	sum := 1.0
+	term := 1.0
+	half := x / 2
+	for k := 1; k < 50; k++ {
+		term *= half / float64(k)
+		sum += term * term
+		if term*term < sum*1e-16 {
+			break
+		}
+	}
+	return sum
+}
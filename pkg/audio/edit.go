@@ -0,0 +1,109 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Reverse 按帧反转音频（各声道的相对顺序保持不变）
+func (a *AudioSegment) Reverse() (*AudioSegment, error) {
+	frames := len(a.samples) / a.channels
+	samples := make([]float64, len(a.samples))
+	for i := 0; i < frames; i++ {
+		src := a.samples[(frames-1-i)*a.channels : (frames-i)*a.channels]
+		copy(samples[i*a.channels:], src)
+	}
+	return a.derive(samples)
+}
+
+// InvertPhase 反转相位，可指定声道索引，未指定时反转所有声道
+func (a *AudioSegment) InvertPhase(channels ...int) (*AudioSegment, error) {
+	invert := make([]bool, a.channels)
+	if len(channels) == 0 {
+		for ch := range invert {
+			invert[ch] = true
+		}
+	}
+	for _, ch := range channels {
+		if ch < 0 || ch >= a.channels {
+			return nil, errors.Errorf("invalid channel index: %d", ch)
+		}
+		invert[ch] = true
+	}
+
+	samples := make([]float64, len(a.samples))
+	for i, sample := range a.samples {
+		if invert[i%a.channels] {
+			sample = -sample
+		}
+		samples[i] = sample
+	}
+	return a.derive(samples)
+}
+
+// Repeat 将音频重复 n 次
+func (a *AudioSegment) Repeat(n int) (*AudioSegment, error) {
+	if n <= 0 {
+		return nil, errors.New("repeat count must be positive")
+	}
+
+	samples := make([]float64, 0, len(a.samples)*n)
+	for i := 0; i < n; i++ {
+		samples = append(samples, a.samples...)
+	}
+	return a.derive(samples)
+}
+
+// PadStart 在开头补充指定时长的静音
+func (a *AudioSegment) PadStart(duration time.Duration) (*AudioSegment, error) {
+	if duration < 0 {
+		return nil, errors.New("padding duration must not be negative")
+	}
+
+	silence := make([]float64, a.framesFor(duration)*a.channels)
+	return a.derive(append(silence, a.samples...))
+}
+
+// PadEnd 在末尾补充指定时长的静音
+func (a *AudioSegment) PadEnd(duration time.Duration) (*AudioSegment, error) {
+	if duration < 0 {
+		return nil, errors.New("padding duration must not be negative")
+	}
+
+	samples := make([]float64, len(a.samples)+a.framesFor(duration)*a.channels)
+	copy(samples, a.samples)
+	return a.derive(samples)
+}
+
+// TrimTo 将音频调整为指定时长：过长时截断末尾，过短时在末尾补静音
+func (a *AudioSegment) TrimTo(duration time.Duration) (*AudioSegment, error) {
+	if duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+
+	frames := a.framesFor(duration)
+	if frames == 0 {
+		return nil, errors.New("duration is shorter than one frame")
+	}
+	samples := make([]float64, frames*a.channels)
+	copy(samples, a.samples)
+	return a.derive(samples)
+}
+
+// framesFor 计算指定时长对应的帧数（四舍五入到最近的帧，避免时长略短于整帧时少一帧）
+func (a *AudioSegment) framesFor(duration time.Duration) int {
+	return int(math.Round(duration.Seconds() * float64(a.sampleRate)))
+}
+
+// derive 使用新的样本创建参数、声道布局和标签相同的音频段
+func (a *AudioSegment) derive(samples []float64) (*AudioSegment, error) {
+	segment, err := NewAudioSegment(samples, a.sampleRate, a.channels, a.bitDepth)
+	if err != nil {
+		return nil, err
+	}
+	segment.layout = a.layout
+	segment.tags = a.tags
+	return segment, nil
+}
@@ -0,0 +1,143 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEditOperations(t *testing.T) {
+	// 立体声，3 帧，采样率 1000Hz
+	segment, err := NewAudioSegment([]float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}, 1000, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		apply    func() (*AudioSegment, error)
+		expected []float64
+	}{
+		{
+			name:     "Reverse",
+			apply:    segment.Reverse,
+			expected: []float64{0.5, 0.6, 0.3, 0.4, 0.1, 0.2},
+		},
+		{
+			name:     "Invert All",
+			apply:    func() (*AudioSegment, error) { return segment.InvertPhase() },
+			expected: []float64{-0.1, -0.2, -0.3, -0.4, -0.5, -0.6},
+		},
+		{
+			name:     "Invert Right",
+			apply:    func() (*AudioSegment, error) { return segment.InvertPhase(1) },
+			expected: []float64{0.1, -0.2, 0.3, -0.4, 0.5, -0.6},
+		},
+		{
+			name:     "Repeat",
+			apply:    func() (*AudioSegment, error) { return segment.Repeat(2) },
+			expected: []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6},
+		},
+		{
+			name:     "Pad Start",
+			apply:    func() (*AudioSegment, error) { return segment.PadStart(time.Millisecond) },
+			expected: []float64{0, 0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6},
+		},
+		{
+			name:     "Pad End",
+			apply:    func() (*AudioSegment, error) { return segment.PadEnd(2 * time.Millisecond) },
+			expected: []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0, 0, 0, 0},
+		},
+		{
+			name:     "Trim Shorter",
+			apply:    func() (*AudioSegment, error) { return segment.TrimTo(2 * time.Millisecond) },
+			expected: []float64{0.1, 0.2, 0.3, 0.4},
+		},
+		{
+			name:     "Trim Longer",
+			apply:    func() (*AudioSegment, error) { return segment.TrimTo(4 * time.Millisecond) },
+			expected: []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.apply()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			samples := result.Samples()
+			if len(samples) != len(tt.expected) {
+				t.Fatalf("expected %d samples, got %d", len(tt.expected), len(samples))
+			}
+			for i := range samples {
+				if samples[i] != tt.expected[i] {
+					t.Errorf("sample %d: expected %f, got %f", i, tt.expected[i], samples[i])
+				}
+			}
+		})
+	}
+
+	if _, err := segment.InvertPhase(2); err == nil {
+		t.Error("expected error for invalid channel index")
+	}
+	if _, err := segment.Repeat(0); err == nil {
+		t.Error("expected error for zero repeat count")
+	}
+}
+
+func TestFramesForRounding(t *testing.T) {
+	segment, err := NewAudioSegment(make([]float64, 441), 44100, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	// 1/44100 秒截断到纳秒后略短于一帧，仍应计为一帧
+	for _, frames := range []int{1, 3, 441, 1000} {
+		duration := time.Duration(frames) * time.Second / 44100
+		padded, err := segment.PadEnd(duration)
+		if err != nil {
+			t.Fatalf("failed to pad segment: %v", err)
+		}
+		if got := len(padded.Samples()) - 441; got != frames {
+			t.Errorf("PadEnd(%v): expected %d frames, got %d", duration, frames, got)
+		}
+		trimmed, err := segment.TrimTo(duration)
+		if err != nil {
+			t.Fatalf("failed to trim segment: %v", err)
+		}
+		if got := len(trimmed.Samples()); got != frames {
+			t.Errorf("TrimTo(%v): expected %d frames, got %d", duration, frames, got)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	segment, err := NewAudioSegment([]float64{0.1, 0.2, 0.3, 0.4}, 1000, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	tagged := segment.WithTags(map[string]string{"Title": "Scene 12", "artist": "Studio"})
+	if len(segment.Tags()) != 0 {
+		t.Errorf("expected original segment to stay untagged, got %v", segment.Tags())
+	}
+	if tagged.Tag("TITLE") != "Scene 12" || tagged.Tags()["title"] != "Scene 12" {
+		t.Errorf("expected title Scene 12, got %v", tagged.Tags())
+	}
+
+	// 编辑操作保留标签，修改返回的副本不影响音频段
+	reversed, err := tagged.Reverse()
+	if err != nil {
+		t.Fatalf("failed to reverse: %v", err)
+	}
+	tags := reversed.Tags()
+	tags["artist"] = "changed"
+	if reversed.Tag("artist") != "Studio" {
+		t.Errorf("expected artist Studio, got %q", reversed.Tag("artist"))
+	}
+
+	data := reversed.toAudioData()
+	if data.Tags["title"] != "Scene 12" {
+		t.Errorf("expected exported title Scene 12, got %v", data.Tags)
+	}
+}
@@ -0,0 +1,28 @@
+package audio
+
+import (
+	"github.com/HiChen85/godub/pkg/converter"
+)
+
+// Export 将音频段导出到文件
+func (a *AudioSegment) Export(path string, format string) error {
+	return a.ExportWithOptions(path, format, converter.ExportOptions{})
+}
+
+// ExportWithOptions 按指定导出选项将音频段导出到文件，声道布局会写入WAV声道掩码，
+// 音频段的标签与 options.Tags 合并后写入输出文件
+func (a *AudioSegment) ExportWithOptions(path string, format string, options converter.ExportOptions) error {
+	return converter.SaveAudioFileWithOptions(a.toAudioData(), path, format, options)
+}
+
+// toAudioData 转换为转换器使用的音频数据
+func (a *AudioSegment) toAudioData() *converter.AudioData {
+	return &converter.AudioData{
+		Samples:     a.samples,
+		SampleRate:  a.sampleRate,
+		Channels:    a.channels,
+		BitDepth:    a.bitDepth,
+		ChannelMask: uint32(a.layout),
+		Tags:        a.tags,
+	}
+}
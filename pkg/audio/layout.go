@@ -0,0 +1,141 @@
+package audio
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// Speaker 扬声器位置，取值与 WAVE_FORMAT_EXTENSIBLE 的 dwChannelMask 位一致
+type Speaker uint32
+
+const (
+	SpeakerFrontLeft          Speaker = 0x1
+	SpeakerFrontRight         Speaker = 0x2
+	SpeakerFrontCenter        Speaker = 0x4
+	SpeakerLowFrequency       Speaker = 0x8
+	SpeakerBackLeft           Speaker = 0x10
+	SpeakerBackRight          Speaker = 0x20
+	SpeakerFrontLeftOfCenter  Speaker = 0x40
+	SpeakerFrontRightOfCenter Speaker = 0x80
+	SpeakerBackCenter         Speaker = 0x100
+	SpeakerSideLeft           Speaker = 0x200
+	SpeakerSideRight          Speaker = 0x400
+)
+
+// speakerNames 扬声器的简称
+var speakerNames = map[Speaker]string{
+	SpeakerFrontLeft:          "FL",
+	SpeakerFrontRight:         "FR",
+	SpeakerFrontCenter:        "FC",
+	SpeakerLowFrequency:       "LFE",
+	SpeakerBackLeft:           "BL",
+	SpeakerBackRight:          "BR",
+	SpeakerFrontLeftOfCenter:  "FLC",
+	SpeakerFrontRightOfCenter: "FRC",
+	SpeakerBackCenter:         "BC",
+	SpeakerSideLeft:           "SL",
+	SpeakerSideRight:          "SR",
+}
+
+// String 返回扬声器简称
+func (s Speaker) String() string {
+	if name, ok := speakerNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ChannelLayout 声道布局，以扬声器位掩码表示，声道顺序按位从低到高排列（与WAV一致）
+type ChannelLayout uint32
+
+// 常用声道布局
+const (
+	LayoutMono    = ChannelLayout(SpeakerFrontCenter)
+	LayoutStereo  = ChannelLayout(SpeakerFrontLeft | SpeakerFrontRight)
+	Layout2_1     = LayoutStereo | ChannelLayout(SpeakerLowFrequency)
+	LayoutQuad    = LayoutStereo | ChannelLayout(SpeakerBackLeft|SpeakerBackRight)
+	Layout5_1     = LayoutStereo | ChannelLayout(SpeakerFrontCenter|SpeakerLowFrequency|SpeakerBackLeft|SpeakerBackRight)
+	Layout5_1Side = LayoutStereo | ChannelLayout(SpeakerFrontCenter|SpeakerLowFrequency|SpeakerSideLeft|SpeakerSideRight)
+	Layout7_1     = Layout5_1 | ChannelLayout(SpeakerSideLeft|SpeakerSideRight)
+)
+
+// layoutNames 常用布局的名称（与 ffmpeg 的命名一致）
+var layoutNames = map[ChannelLayout]string{
+	LayoutMono:    "mono",
+	LayoutStereo:  "stereo",
+	Layout2_1:     "2.1",
+	LayoutQuad:    "quad",
+	Layout5_1:     "5.1",
+	Layout5_1Side: "5.1(side)",
+	Layout7_1:     "7.1",
+}
+
+// DefaultLayout 返回指定声道数的默认布局，没有标准布局时返回 0
+func DefaultLayout(channels int) ChannelLayout {
+	switch channels {
+	case 1:
+		return LayoutMono
+	case 2:
+		return LayoutStereo
+	case 3:
+		return Layout2_1
+	case 4:
+		return LayoutQuad
+	case 6:
+		return Layout5_1
+	case 8:
+		return Layout7_1
+	default:
+		return 0
+	}
+}
+
+// ParseLayout 解析布局名称（如 "5.1"、"stereo"），无法识别时返回 false
+func ParseLayout(name string) (ChannelLayout, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for layout, layoutName := range layoutNames {
+		if layoutName == name {
+			return layout, true
+		}
+	}
+	return 0, false
+}
+
+// Channels 返回布局中的声道数
+func (l ChannelLayout) Channels() int {
+	return bits.OnesCount32(uint32(l))
+}
+
+// Speakers 按声道顺序返回布局中的扬声器
+func (l ChannelLayout) Speakers() []Speaker {
+	speakers := make([]Speaker, 0, l.Channels())
+	for mask := uint32(l); mask != 0; mask &= mask - 1 {
+		speakers = append(speakers, Speaker(mask&-mask))
+	}
+	return speakers
+}
+
+// Has 判断布局是否包含指定扬声器
+func (l ChannelLayout) Has(speaker Speaker) bool {
+	return uint32(l)&uint32(speaker) != 0
+}
+
+// Index 返回扬声器在布局中的声道索引，不存在时返回 -1
+func (l ChannelLayout) Index(speaker Speaker) int {
+	if !l.Has(speaker) {
+		return -1
+	}
+	return bits.OnesCount32(uint32(l) & (uint32(speaker) - 1))
+}
+
+// String 返回布局名称，非常用布局返回扬声器列表
+func (l ChannelLayout) String() string {
+	if name, ok := layoutNames[l]; ok {
+		return name
+	}
+	names := make([]string, 0, l.Channels())
+	for _, speaker := range l.Speakers() {
+		names = append(names, speaker.String())
+	}
+	return strings.Join(names, "+")
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/HiChen85/godub/pkg/converter"
 )
@@ -15,12 +16,7 @@ func FromFile(path string, format ...string) (*AudioSegment, error) {
 		return nil, fmt.Errorf("file does not exist: %s", path)
 	}
 
-	var audioFormat string
-	if len(format) > 0 {
-		audioFormat = format[0]
-	} else {
-		audioFormat = strings.TrimPrefix(filepath.Ext(path), ".")
-	}
+	audioFormat := resolveFormat(path, format)
 
 	// 使用转换器加载音频文件
 	audio, err := converter.LoadAudioFile(path, audioFormat)
@@ -28,7 +24,80 @@ func FromFile(path string, format ...string) (*AudioSegment, error) {
 		return nil, fmt.Errorf("failed to load audio file: %w", err)
 	}
 
-	return NewAudioSegment(audio.Samples, audio.SampleRate, audio.Channels, audio.BitDepth)
+	return fromAudioData(audio)
+}
+
+// FromFileRange 只加载文件中从 start 开始、时长为 duration 的片段（duration 不大于 0 时到文件末尾），
+// WAV 文件直接按字节偏移读取，其他格式由 ffmpeg 定位后只解码所需部分
+func FromFileRange(path string, start, duration time.Duration, format ...string) (*AudioSegment, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file does not exist: %s", path)
+	}
+
+	audioFormat := resolveFormat(path, format)
+	audio, err := converter.LoadAudioFileRange(path, audioFormat, start, duration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audio range: %w", err)
+	}
+
+	return fromAudioData(audio)
+}
+
+// FromFileWithOptions 从多音轨文件（包括 mkv/mp4/mov 等视频容器）中加载按选项选中的音频流
+func FromFileWithOptions(path string, options converter.LoadOptions, format ...string) (*AudioSegment, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file does not exist: %s", path)
+	}
+
+	audioFormat := resolveFormat(path, format)
+	audio, err := converter.LoadAudioFileWithOptions(path, audioFormat, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audio stream: %w", err)
+	}
+
+	return fromAudioData(audio)
+}
+
+// FromFileAllStreams 将文件中的每个音频流分别加载为音频段，按流顺序返回
+// 每个音频段的标签中包含该流的 language 和 title（如果有）
+func FromFileAllStreams(path string) ([]*AudioSegment, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file does not exist: %s", path)
+	}
+
+	audios, _, err := converter.LoadAllAudioStreams(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audio streams: %w", err)
+	}
+
+	segments := make([]*AudioSegment, len(audios))
+	for i, audio := range audios {
+		if segments[i], err = fromAudioData(audio); err != nil {
+			return nil, err
+		}
+	}
+	return segments, nil
+}
+
+// resolveFormat 返回调用方指定的格式，未指定时取文件扩展名
+func resolveFormat(path string, format []string) string {
+	if len(format) > 0 {
+		return format[0]
+	}
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
+
+// fromAudioData 由转换器输出的音频数据创建音频段，保留声道布局和元数据标签
+func fromAudioData(audio *converter.AudioData) (*AudioSegment, error) {
+	segment, err := NewAudioSegment(audio.Samples, audio.SampleRate, audio.Channels, audio.BitDepth)
+	if err != nil {
+		return nil, err
+	}
+	if layout := ChannelLayout(audio.ChannelMask); audio.ChannelMask != 0 && layout.Channels() == audio.Channels {
+		segment.layout = layout
+	}
+	segment.tags = audio.Tags
+	return segment, nil
 }
 
 // FromMP3 从MP3文件加载音频
@@ -0,0 +1,226 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// DownmixMode 缩混方式
+type DownmixMode int
+
+const (
+	// DownmixITU ITU-R BS.775 缩混：中置和环绕以 -3dB 混入前置，丢弃 LFE
+	DownmixITU DownmixMode = iota
+	// DownmixDolby Dolby Pro Logic II 风格的 Lt/Rt 矩阵缩混（仅用于缩混为立体声），
+	// 环绕声道带相位偏移混入，可被解码器还原
+	DownmixDolby
+)
+
+// 常用混音系数
+const (
+	minus3dB = math.Sqrt2 / 2
+	minus6dB = 0.5
+)
+
+// Downmix 按指定方式将音频缩混为声道较少的目标布局
+func (a *AudioSegment) Downmix(target ChannelLayout, mode DownmixMode) (*AudioSegment, error) {
+	if a.layout == 0 {
+		return nil, errors.New("segment has no channel layout")
+	}
+	if target.Channels() == 0 {
+		return nil, errors.New("target layout is empty")
+	}
+
+	var matrix [][]float64
+	if mode == DownmixDolby {
+		if target != LayoutStereo {
+			return nil, errors.New("dolby downmix only supports a stereo target")
+		}
+		matrix = dolbyMatrix(a.layout)
+	} else {
+		matrix = ituMatrix(a.layout, target)
+	}
+
+	return a.applyMatrix(matrix, target)
+}
+
+// Upmix 将音频扩展为声道较多的目标布局：已有声道直接映射，
+// 立体声源会被动提取中置（L+R）和环绕（L-R）信号，单声道源在没有中置声道时以 -3dB 送入左右
+func (a *AudioSegment) Upmix(target ChannelLayout) (*AudioSegment, error) {
+	if a.layout == 0 {
+		return nil, errors.New("segment has no channel layout")
+	}
+	if target.Channels() == 0 {
+		return nil, errors.New("target layout is empty")
+	}
+	if target.Channels() < a.layout.Channels() {
+		return nil, errors.New("target layout has fewer channels than the source")
+	}
+	// 单声道源的中置可改送左右声道，其他源的每个扬声器都必须在目标中存在，否则会被静默丢弃
+	if a.layout != LayoutMono && target&a.layout != a.layout {
+		return nil, errors.New("target layout is missing speakers of the source")
+	}
+
+	matrix := newMatrix(target, a.layout)
+	for s, speaker := range a.layout.Speakers() {
+		if t := target.Index(speaker); t >= 0 {
+			matrix[t][s] = 1
+		}
+	}
+
+	switch a.layout {
+	case LayoutMono:
+		if !target.Has(SpeakerFrontCenter) {
+			left, right := target.Index(SpeakerFrontLeft), target.Index(SpeakerFrontRight)
+			if left < 0 || right < 0 {
+				return nil, errors.New("target layout needs a front center or front left/right pair for a mono source")
+			}
+			matrix[left][0] = minus3dB
+			matrix[right][0] = minus3dB
+		}
+	case LayoutStereo:
+		if t := target.Index(SpeakerFrontCenter); t >= 0 {
+			matrix[t][0] = minus3dB * minus6dB
+			matrix[t][1] = minus3dB * minus6dB
+		}
+		// 环绕声道取差信号（立体声中的环境声），左右反相
+		for _, pair := range [][2]Speaker{{SpeakerBackLeft, SpeakerBackRight}, {SpeakerSideLeft, SpeakerSideRight}} {
+			left, right := target.Index(pair[0]), target.Index(pair[1])
+			if left < 0 || right < 0 {
+				continue
+			}
+			matrix[left][0], matrix[left][1] = minus3dB*minus6dB, -minus3dB*minus6dB
+			matrix[right][0], matrix[right][1] = -minus3dB*minus6dB, minus3dB*minus6dB
+		}
+	}
+
+	return a.applyMatrix(matrix, target)
+}
+
+// newMatrix 创建 目标声道 × 源声道 的零矩阵
+func newMatrix(target, source ChannelLayout) [][]float64 {
+	matrix := make([][]float64, target.Channels())
+	for i := range matrix {
+		matrix[i] = make([]float64, source.Channels())
+	}
+	return matrix
+}
+
+// ituMatrix 按 ITU-R BS.775 规则构建缩混矩阵
+func ituMatrix(source, target ChannelLayout) [][]float64 {
+	matrix := newMatrix(target, source)
+
+	// route 将源声道按系数送到目标扬声器，目标不存在时返回 false
+	route := func(s int, speaker Speaker, gain float64) bool {
+		t := target.Index(speaker)
+		if t < 0 {
+			return false
+		}
+		matrix[t][s] += gain
+		return true
+	}
+	// routeFront 送入左右前置，目标为单声道时送入中置
+	routeFront := func(s int, left, right float64) {
+		hasLeft := left != 0 && route(s, SpeakerFrontLeft, left)
+		hasRight := right != 0 && route(s, SpeakerFrontRight, right)
+		if !hasLeft && !hasRight {
+			route(s, SpeakerFrontCenter, minus6dB*(left+right))
+		}
+	}
+
+	for s, speaker := range source.Speakers() {
+		if route(s, speaker, 1) {
+			continue
+		}
+		switch speaker {
+		case SpeakerFrontLeft, SpeakerFrontLeftOfCenter:
+			routeFront(s, 1, 0)
+		case SpeakerFrontRight, SpeakerFrontRightOfCenter:
+			routeFront(s, 0, 1)
+		case SpeakerFrontCenter:
+			routeFront(s, minus3dB, minus3dB)
+		case SpeakerBackLeft:
+			if !route(s, SpeakerSideLeft, 1) {
+				routeFront(s, minus3dB, 0)
+			}
+		case SpeakerBackRight:
+			if !route(s, SpeakerSideRight, 1) {
+				routeFront(s, 0, minus3dB)
+			}
+		case SpeakerSideLeft:
+			if !route(s, SpeakerBackLeft, 1) {
+				routeFront(s, minus3dB, 0)
+			}
+		case SpeakerSideRight:
+			if !route(s, SpeakerBackRight, 1) {
+				routeFront(s, 0, minus3dB)
+			}
+		case SpeakerBackCenter:
+			if !(target.Has(SpeakerBackLeft) && route(s, SpeakerBackLeft, minus3dB) && route(s, SpeakerBackRight, minus3dB)) &&
+				!(target.Has(SpeakerSideLeft) && route(s, SpeakerSideLeft, minus3dB) && route(s, SpeakerSideRight, minus3dB)) {
+				routeFront(s, minus6dB, minus6dB)
+			}
+		}
+		// LFE 在目标没有低频声道时丢弃
+	}
+	return matrix
+}
+
+// dolbyMatrix 构建 Dolby Pro Logic II 风格的 Lt/Rt 缩混矩阵
+func dolbyMatrix(source ChannelLayout) [][]float64 {
+	matrix := newMatrix(LayoutStereo, source)
+	for s, speaker := range source.Speakers() {
+		switch speaker {
+		case SpeakerFrontLeft, SpeakerFrontLeftOfCenter:
+			matrix[0][s] = 1
+		case SpeakerFrontRight, SpeakerFrontRightOfCenter:
+			matrix[1][s] = 1
+		case SpeakerFrontCenter:
+			matrix[0][s], matrix[1][s] = minus3dB, minus3dB
+		case SpeakerBackLeft, SpeakerSideLeft:
+			matrix[0][s], matrix[1][s] = -0.8718, 0.4903
+		case SpeakerBackRight, SpeakerSideRight:
+			matrix[0][s], matrix[1][s] = -0.4903, 0.8718
+		case SpeakerBackCenter:
+			matrix[0][s], matrix[1][s] = -minus3dB, minus3dB
+		}
+	}
+
+	// 7.1 同时有后置和侧置环绕时两组各分一半能量
+	if source.Has(SpeakerBackLeft) && source.Has(SpeakerSideLeft) {
+		for _, speaker := range []Speaker{SpeakerBackLeft, SpeakerBackRight, SpeakerSideLeft, SpeakerSideRight} {
+			s := source.Index(speaker)
+			matrix[0][s] *= minus3dB
+			matrix[1][s] *= minus3dB
+		}
+	}
+	return matrix
+}
+
+// applyMatrix 按 目标声道 × 源声道 矩阵重新混音
+func (a *AudioSegment) applyMatrix(matrix [][]float64, target ChannelLayout) (*AudioSegment, error) {
+	frames := len(a.samples) / a.channels
+	outChannels := target.Channels()
+	samples := make([]float64, frames*outChannels)
+
+	for i := 0; i < frames; i++ {
+		in := a.samples[i*a.channels : (i+1)*a.channels]
+		out := samples[i*outChannels : (i+1)*outChannels]
+		for t, row := range matrix {
+			var sum float64
+			for s, gain := range row {
+				sum += gain * in[s]
+			}
+			out[t] = sum
+		}
+	}
+
+	segment, err := NewAudioSegment(samples, a.sampleRate, outChannels, a.bitDepth)
+	if err != nil {
+		return nil, err
+	}
+	segment.layout = target
+	segment.tags = a.tags
+	return segment, nil
+}
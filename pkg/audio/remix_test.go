@@ -0,0 +1,144 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChannelLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   ChannelLayout
+		channels int
+		speakers string
+	}{
+		{name: "Mono", layout: LayoutMono, channels: 1, speakers: "mono"},
+		{name: "Stereo", layout: LayoutStereo, channels: 2, speakers: "stereo"},
+		{name: "5.1", layout: Layout5_1, channels: 6, speakers: "5.1"},
+		{name: "7.1", layout: Layout7_1, channels: 8, speakers: "7.1"},
+		{name: "Custom", layout: ChannelLayout(SpeakerFrontLeft | SpeakerFrontRight | SpeakerBackCenter), channels: 3, speakers: "FL+FR+BC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.layout.Channels() != tt.channels {
+				t.Errorf("expected %d channels, got %d", tt.channels, tt.layout.Channels())
+			}
+			if tt.layout.String() != tt.speakers {
+				t.Errorf("expected %q, got %q", tt.speakers, tt.layout.String())
+			}
+		})
+	}
+
+	if idx := Layout5_1.Index(SpeakerLowFrequency); idx != 3 {
+		t.Errorf("expected LFE at index 3, got %d", idx)
+	}
+	if layout, ok := ParseLayout("5.1(side)"); !ok || layout != Layout5_1Side {
+		t.Errorf("failed to parse 5.1(side), got %v", layout)
+	}
+}
+
+func TestDownmix(t *testing.T) {
+	// 一帧 5.1：FL FR FC LFE BL BR
+	segment, err := NewAudioSegment([]float64{0.1, 0.2, 0.4, 0.9, 0.3, -0.3}, 48000, 6, 24)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	if segment.Layout() != Layout5_1 {
+		t.Fatalf("expected default 5.1 layout, got %v", segment.Layout())
+	}
+
+	stereo, err := segment.Downmix(LayoutStereo, DownmixITU)
+	if err != nil {
+		t.Fatalf("failed to downmix: %v", err)
+	}
+	c := math.Sqrt2 / 2
+	expected := []float64{0.1 + c*0.4 + c*0.3, 0.2 + c*0.4 - c*0.3}
+	for i, sample := range stereo.Samples() {
+		if math.Abs(sample-expected[i]) > 1e-12 {
+			t.Errorf("channel %d: expected %f, got %f", i, expected[i], sample)
+		}
+	}
+
+	mono, err := stereo.Downmix(LayoutMono, DownmixITU)
+	if err != nil {
+		t.Fatalf("failed to downmix: %v", err)
+	}
+	if v := mono.Samples()[0]; math.Abs(v-(expected[0]+expected[1])/2) > 1e-12 {
+		t.Errorf("expected mono sample %f, got %f", (expected[0]+expected[1])/2, v)
+	}
+
+	if _, err := segment.Downmix(LayoutMono, DownmixDolby); err == nil {
+		t.Error("expected error for dolby downmix to mono")
+	}
+}
+
+func TestUpmix(t *testing.T) {
+	segment, err := NewAudioSegment([]float64{0.5, 0.1}, 48000, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	surround, err := segment.Upmix(Layout5_1)
+	if err != nil {
+		t.Fatalf("failed to upmix: %v", err)
+	}
+	if surround.Channels() != 6 || surround.Layout() != Layout5_1 {
+		t.Fatalf("expected 5.1 output, got %d channels (%v)", surround.Channels(), surround.Layout())
+	}
+
+	samples := surround.Samples()
+	if samples[0] != 0.5 || samples[1] != 0.1 {
+		t.Errorf("expected front channels to pass through, got %f %f", samples[0], samples[1])
+	}
+	if samples[3] != 0 {
+		t.Errorf("expected silent LFE, got %f", samples[3])
+	}
+	if samples[4] != -samples[5] {
+		t.Errorf("expected opposite-phase surrounds, got %f %f", samples[4], samples[5])
+	}
+}
+
+func TestUpmixInvalidTarget(t *testing.T) {
+	mono, err := NewAudioSegment([]float64{0.5}, 48000, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	surround, err := NewAudioSegment(make([]float64, 6), 48000, 6, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	quad, err := NewAudioSegment(make([]float64, 4), 48000, 4, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		segment *AudioSegment
+		target  ChannelLayout
+	}{
+		{"empty target", mono, 0},
+		{"mono to back pair", mono, ChannelLayout(SpeakerBackLeft | SpeakerBackRight)},
+		{"mono to front left only", mono, ChannelLayout(SpeakerFrontLeft | SpeakerLowFrequency)},
+		{"fewer channels", surround, LayoutStereo},
+		{"quad back pair dropped", quad, Layout5_1Side},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.segment.Upmix(tt.target); err == nil {
+				t.Errorf("expected error for target %v", tt.target)
+			}
+		})
+	}
+
+	// 单声道送入只有左右前置的布局时以 -3dB 分配
+	stereo, err := mono.Upmix(LayoutStereo)
+	if err != nil {
+		t.Fatalf("failed to upmix: %v", err)
+	}
+	if samples := stereo.Samples(); math.Abs(samples[0]-0.5*math.Sqrt2/2) > 1e-12 || samples[0] != samples[1] {
+		t.Errorf("expected -3dB front pair, got %v", samples)
+	}
+}
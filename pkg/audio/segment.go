@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -14,10 +15,14 @@ type AudioSegment struct {
 	sampleRate int
 	// 声道数
 	channels int
+	// 声道布局
+	layout ChannelLayout
 	// 位深度
 	bitDepth int
 	// 音频时长
 	duration time.Duration
+	// 元数据标签（标签名为小写的通用名称，如 title、artist）
+	tags map[string]string
 }
 
 // NewAudioSegment 创建一个新的音频段
@@ -41,6 +46,7 @@ func NewAudioSegment(samples []float64, sampleRate, channels, bitDepth int) (*Au
 		samples:    samples,
 		sampleRate: sampleRate,
 		channels:   channels,
+		layout:     DefaultLayout(channels),
 		bitDepth:   bitDepth,
 		duration:   duration,
 	}, nil
@@ -61,6 +67,45 @@ func (a *AudioSegment) Channels() int {
 	return a.channels
 }
 
+// Layout 返回声道布局，声道数没有标准布局时为 0
+func (a *AudioSegment) Layout() ChannelLayout {
+	return a.layout
+}
+
+// WithLayout 返回使用指定声道布局的音频段，布局的声道数必须与音频一致
+func (a *AudioSegment) WithLayout(layout ChannelLayout) (*AudioSegment, error) {
+	if layout.Channels() != a.channels {
+		return nil, errors.New("layout does not match channel count")
+	}
+	segment := *a
+	segment.layout = layout
+	return &segment, nil
+}
+
+// Tags 返回元数据标签的副本
+func (a *AudioSegment) Tags() map[string]string {
+	tags := make(map[string]string, len(a.tags))
+	for key, value := range a.tags {
+		tags[key] = value
+	}
+	return tags
+}
+
+// Tag 返回指定标签的值，标签名不区分大小写
+func (a *AudioSegment) Tag(name string) string {
+	return a.tags[strings.ToLower(name)]
+}
+
+// WithTags 返回使用指定元数据标签的音频段，标签名统一转换为小写
+func (a *AudioSegment) WithTags(tags map[string]string) *AudioSegment {
+	segment := *a
+	segment.tags = make(map[string]string, len(tags))
+	for key, value := range tags {
+		segment.tags[strings.ToLower(key)] = value
+	}
+	return &segment
+}
+
 // BitDepth 返回位深度
 func (a *AudioSegment) BitDepth() int {
 	return a.bitDepth
@@ -80,10 +125,43 @@ func (a *AudioSegment) Slice(start, end time.Duration) (*AudioSegment, error) {
 	startSample := int(float64(start) * float64(a.sampleRate*a.channels) / float64(time.Second))
 	endSample := int(float64(end) * float64(a.sampleRate*a.channels) / float64(time.Second))
 
-	return NewAudioSegment(
-		a.samples[startSample:endSample],
-		a.sampleRate,
-		a.channels,
-		a.bitDepth,
-	)
+	return a.derive(a.samples[startSample:endSample])
+}
+
+// SplitChannels 将交错存储的样本按声道拆分
+func (a *AudioSegment) SplitChannels() [][]float64 {
+	frames := len(a.samples) / a.channels
+	data := make([][]float64, a.channels)
+	for ch := range data {
+		data[ch] = make([]float64, frames)
+	}
+	for i := 0; i < frames; i++ {
+		for ch := 0; ch < a.channels; ch++ {
+			data[ch][i] = a.samples[i*a.channels+ch]
+		}
+	}
+	return data
+}
+
+// NewAudioSegmentFromChannels 由按声道存储的样本创建音频段
+func NewAudioSegmentFromChannels(data [][]float64, sampleRate, bitDepth int) (*AudioSegment, error) {
+	if len(data) == 0 {
+		return nil, errors.New("channels must be positive")
+	}
+	frames := len(data[0])
+	for _, ch := range data {
+		if len(ch) != frames {
+			return nil, errors.New("all channels must have the same length")
+		}
+	}
+
+	channels := len(data)
+	samples := make([]float64, frames*channels)
+	for i := 0; i < frames; i++ {
+		for ch := 0; ch < channels; ch++ {
+			samples[i*channels+ch] = data[ch][i]
+		}
+	}
+
+	return NewAudioSegment(samples, sampleRate, channels, bitDepth)
 }
@@ -0,0 +1,401 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// 通用标签名对应的 AIFF 文本块 ID
+var aiffTextIDs = map[string]string{
+	"title":     "NAME",
+	"artist":    "AUTH",
+	"copyright": "(c) ",
+	"comment":   "ANNO",
+}
+
+// AIFF 文本块 ID 对应的通用标签名
+var aiffTextNames = map[string]string{
+	"NAME": "title",
+	"AUTH": "artist",
+	"(c) ": "copyright",
+	"ANNO": "comment",
+}
+
+// AIFFBackend 纯 Go 实现的 AIFF 后端，支持大端整数PCM（AIFF 及未压缩的 AIFF-C），不需要 ffmpeg
+type AIFFBackend struct{}
+
+func init() {
+	RegisterBackend("aiff", AIFFBackend{})
+	RegisterBackend("aif", AIFFBackend{})
+}
+
+// aiffInfo 解析 AIFF 文件头得到的格式信息和声音数据位置
+type aiffInfo struct {
+	SampleRate   int
+	Channels     int
+	BitDepth     int
+	Frames       int64
+	LittleEndian bool  // AIFF-C 的 sowt 编码为小端序
+	DataOffset   int64 // 第一个采样帧在文件中的位置
+	Tags         map[string]string
+}
+
+// Name 实现 Backend 接口
+func (AIFFBackend) Name() string {
+	return "aiff"
+}
+
+// Probe 实现 Backend 接口
+func (AIFFBackend) Probe(path string) (*MediaInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open aiff file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := readAIFFInfo(f)
+	if err != nil {
+		return nil, unsupported("%v", err)
+	}
+	media := info.mediaInfo()
+	if stat, err := f.Stat(); err == nil {
+		media.Size = stat.Size()
+	}
+	return media, nil
+}
+
+// Decode 实现 Backend 接口，压缩编码或需要重采样、改变声道数时返回 ErrUnsupported
+func (AIFFBackend) Decode(path string, options DecodeOptions) (*AudioData, error) {
+	if options.Start < 0 {
+		return nil, fmt.Errorf("start cannot be negative")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open aiff file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := readAIFFInfo(f)
+	if err != nil {
+		return nil, unsupported("%v", err)
+	}
+	if options.SampleRate > 0 && options.SampleRate != info.SampleRate {
+		return nil, unsupported("resampling from %d Hz to %d Hz", info.SampleRate, options.SampleRate)
+	}
+	if options.Channels > 0 && options.Channels != info.Channels {
+		return nil, unsupported("converting %d channels to %d", info.Channels, options.Channels)
+	}
+	if _, err := info.mediaInfo().SelectAudioStream(options.LoadOptions); err != nil {
+		return nil, err
+	}
+
+	frameSize := int64(info.Channels * info.BitDepth / 8)
+	startFrame := int64(options.Start.Seconds() * float64(info.SampleRate))
+	if startFrame > 0 && startFrame >= info.Frames {
+		return nil, fmt.Errorf("start %v is beyond the end of the file", options.Start)
+	}
+	frames := info.Frames - startFrame
+	if options.Duration > 0 {
+		if n := int64(options.Duration.Seconds() * float64(info.SampleRate)); n < frames {
+			frames = n
+		}
+	}
+
+	if _, err := f.Seek(info.DataOffset+startFrame*frameSize, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek aiff data: %w", err)
+	}
+	data := make([]byte, frames*frameSize)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, fmt.Errorf("failed to read aiff data: %w", err)
+	}
+
+	bitDepth := info.BitDepth
+	if options.BitDepth > 0 {
+		bitDepth = options.BitDepth
+	}
+	return &AudioData{
+		Samples:    decodeAIFFSamples(data, info.BitDepth, info.LittleEndian),
+		SampleRate: info.SampleRate,
+		Channels:   info.Channels,
+		BitDepth:   bitDepth,
+		Tags:       info.Tags,
+	}, nil
+}
+
+// Encode 实现 Backend 接口
+func (AIFFBackend) Encode(audio *AudioData, path string, format string, options ExportOptions) error {
+	if options.CoverArt != "" {
+		return fmt.Errorf("cover art is not supported for %s", format)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create aiff file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := writeAIFF(w, audio, mergeTags(audio.Tags, options.Tags), options); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write aiff file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync aiff file: %w", err)
+	}
+	return nil
+}
+
+// mediaInfo 将 AIFF 格式信息转换为只有一个音频流的媒体信息
+func (a *aiffInfo) mediaInfo() *MediaInfo {
+	duration := time.Duration(float64(a.Frames) / float64(a.SampleRate) * float64(time.Second))
+	bitRate := int64(a.SampleRate * a.Channels * a.BitDepth)
+	return &MediaInfo{
+		Format:         "aiff",
+		FormatLongName: "Audio IFF",
+		Duration:       duration,
+		BitRate:        bitRate,
+		Tags:           a.Tags,
+		Streams: []StreamInfo{{
+			CodecType:     "audio",
+			CodecName:     a.codecName(),
+			SampleRate:    a.SampleRate,
+			Channels:      a.Channels,
+			BitsPerSample: a.BitDepth,
+			BitRate:       bitRate,
+			Duration:      duration,
+			Disposition:   Disposition{Default: true},
+		}},
+	}
+}
+
+// codecName 返回 ffmpeg 风格的编码名称
+func (a *aiffInfo) codecName() string {
+	if a.BitDepth == 8 {
+		return "pcm_s8"
+	}
+	if a.LittleEndian {
+		return fmt.Sprintf("pcm_s%dle", a.BitDepth)
+	}
+	return fmt.Sprintf("pcm_s%dbe", a.BitDepth)
+}
+
+// readAIFFInfo 遍历 IFF 块解析 COMM、SSND 和文本块，不读取样本数据
+func readAIFFInfo(r io.ReadSeeker) (*aiffInfo, error) {
+	var form [12]byte
+	if _, err := io.ReadFull(r, form[:]); err != nil {
+		return nil, fmt.Errorf("failed to read form header: %w", err)
+	}
+	formType := string(form[8:12])
+	if string(form[0:4]) != "FORM" || (formType != "AIFF" && formType != "AIFC") {
+		return nil, fmt.Errorf("not an aiff file")
+	}
+
+	info := &aiffInfo{DataOffset: -1}
+	offset := int64(12)
+	var haveCommon bool
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			break
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.BigEndian.Uint32(chunk[4:8]))
+		offset += 8
+
+		switch id {
+		case "COMM":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("failed to read comm chunk: %w", err)
+			}
+			if err := info.parseCommon(body, formType == "AIFC"); err != nil {
+				return nil, err
+			}
+			haveCommon = true
+		case "SSND":
+			var header [8]byte
+			if _, err := io.ReadFull(r, header[:]); err != nil {
+				return nil, fmt.Errorf("failed to read ssnd chunk: %w", err)
+			}
+			info.DataOffset = offset + 8 + int64(binary.BigEndian.Uint32(header[0:4]))
+		case "NAME", "AUTH", "(c) ", "ANNO":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("failed to read text chunk: %w", err)
+			}
+			if value := strings.TrimRight(string(body), "\x00 "); value != "" {
+				if info.Tags == nil {
+					info.Tags = make(map[string]string)
+				}
+				info.Tags[aiffTextNames[id]] = value
+			}
+		}
+
+		// 块按偶数字节对齐
+		offset += size + size%2
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	if !haveCommon {
+		return nil, fmt.Errorf("missing comm chunk")
+	}
+	if info.DataOffset < 0 {
+		return nil, fmt.Errorf("missing ssnd chunk")
+	}
+	return info, nil
+}
+
+// parseCommon 解析 COMM 块，AIFF-C 只支持未压缩（NONE）和小端（sowt）编码
+func (a *aiffInfo) parseCommon(body []byte, compressed bool) error {
+	if len(body) < 18 {
+		return fmt.Errorf("comm chunk too short")
+	}
+	a.Channels = int(binary.BigEndian.Uint16(body[0:2]))
+	a.Frames = int64(binary.BigEndian.Uint32(body[2:6]))
+	a.BitDepth = int(binary.BigEndian.Uint16(body[6:8]))
+	a.SampleRate = int(math.Round(decodeExtended(body[8:18])))
+
+	if compressed {
+		if len(body) < 22 {
+			return fmt.Errorf("aifc comm chunk too short")
+		}
+		switch compression := string(body[18:22]); compression {
+		case "NONE":
+		case "sowt":
+			a.LittleEndian = true
+		default:
+			return fmt.Errorf("unsupported aifc compression %q", compression)
+		}
+	}
+
+	switch a.BitDepth {
+	case 8, 16, 24, 32:
+	default:
+		return fmt.Errorf("unsupported aiff bit depth: %d", a.BitDepth)
+	}
+	if a.Channels <= 0 || a.SampleRate <= 0 {
+		return fmt.Errorf("invalid aiff format")
+	}
+	return nil
+}
+
+// decodeAIFFSamples 将有符号PCM字节数据转换为 [-1, 1] 范围的浮点样本
+func decodeAIFFSamples(data []byte, bitDepth int, littleEndian bool) []float64 {
+	bytesPerSample := bitDepth / 8
+	scale := math.Ldexp(1, bitDepth-1)
+	samples := make([]float64, len(data)/bytesPerSample)
+	for i := range samples {
+		b := data[i*bytesPerSample : (i+1)*bytesPerSample]
+		var value int32
+		for k := 0; k < bytesPerSample; k++ {
+			index := k
+			if littleEndian {
+				index = bytesPerSample - 1 - k
+			}
+			value = value<<8 | int32(b[index])
+		}
+		// 符号扩展
+		shift := 32 - bitDepth
+		value = value << shift >> shift
+		samples[i] = float64(value) / scale
+	}
+	return samples
+}
+
+// writeAIFF 写入 AIFF 文件：FORM 头、COMM 块、文本块和 SSND 块
+func writeAIFF(w io.Writer, audio *AudioData, tags map[string]string, options ExportOptions) error {
+	bytesPerSample := audio.BitDepth / 8
+	dataSize := len(audio.Samples) * bytesPerSample
+	frames := len(audio.Samples) / audio.Channels
+
+	var text bytes.Buffer
+	for _, name := range sortedKeys(tags) {
+		id, ok := aiffTextIDs[name]
+		if !ok {
+			continue
+		}
+		value := []byte(tags[name])
+		text.WriteString(id)
+		binary.Write(&text, binary.BigEndian, uint32(len(value)))
+		text.Write(value)
+		if len(value)%2 == 1 {
+			text.WriteByte(0)
+		}
+	}
+
+	var header bytes.Buffer
+	header.WriteString("FORM")
+	binary.Write(&header, binary.BigEndian, uint32(4+8+18+text.Len()+8+8+dataSize+dataSize%2))
+	header.WriteString("AIFF")
+	header.WriteString("COMM")
+	binary.Write(&header, binary.BigEndian, uint32(18))
+	binary.Write(&header, binary.BigEndian, uint16(audio.Channels))
+	binary.Write(&header, binary.BigEndian, uint32(frames))
+	binary.Write(&header, binary.BigEndian, uint16(audio.BitDepth))
+	header.Write(encodeExtended(float64(audio.SampleRate)))
+	header.Write(text.Bytes())
+	header.WriteString("SSND")
+	binary.Write(&header, binary.BigEndian, uint32(8+dataSize))
+	binary.Write(&header, binary.BigEndian, uint32(0)) // offset
+	binary.Write(&header, binary.BigEndian, uint32(0)) // block size
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return fmt.Errorf("failed to write aiff header: %w", err)
+	}
+
+	quantizer := NewQuantizer(audio.BitDepth, audio.Channels, options.Dither, options.NoiseShaping)
+	buf := make([]byte, bytesPerSample)
+	for _, sample := range audio.Samples {
+		value := quantizer.Quantize(sample)
+		for k := 0; k < bytesPerSample; k++ {
+			buf[k] = byte(value >> (8 * (bytesPerSample - 1 - k)))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("failed to write aiff data: %w", err)
+		}
+	}
+	if dataSize%2 == 1 {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return fmt.Errorf("failed to write aiff data: %w", err)
+		}
+	}
+	return nil
+}
+
+// decodeExtended 解析 80 位 IEEE 754 扩展精度浮点数（AIFF 采样率）
+func decodeExtended(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]))
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	sign := 1.0
+	if exponent&0x8000 != 0 {
+		sign = -1
+		exponent &= 0x7fff
+	}
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+	return sign * math.Ldexp(float64(mantissa), exponent-16383-63)
+}
+
+// encodeExtended 将正数编码为 80 位 IEEE 754 扩展精度浮点数
+func encodeExtended(value float64) []byte {
+	b := make([]byte, 10)
+	if value <= 0 {
+		return b
+	}
+	frac, exp := math.Frexp(value) // value = frac * 2^exp，frac 在 [0.5, 1)
+	binary.BigEndian.PutUint16(b[0:2], uint16(exp-1+16383))
+	binary.BigEndian.PutUint64(b[2:10], uint64(math.Ldexp(frac, 64)))
+	return b
+}
@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnsupported 后端不支持该文件或参数，调用方会回退到 ffmpeg 后端
+var ErrUnsupported = errors.New("unsupported by backend")
+
+// DecodeOptions 解码选项，零值表示解码第一个音频流的全部内容并保持原始格式
+type DecodeOptions struct {
+	LoadOptions               // 选择的音频流
+	Start       time.Duration // 起始位置
+	Duration    time.Duration // 时长，不大于 0 时解码到末尾
+	SampleRate  int           // 目标采样率，0 表示保持原始采样率
+	Channels    int           // 目标声道数，0 表示保持原始声道数
+	BitDepth    int           // 目标位深度，0 表示按源文件选择
+}
+
+// Backend 编解码后端，按格式注册到 RegisterBackend
+type Backend interface {
+	// Name 返回后端名称
+	Name() string
+	// Probe 读取媒体信息
+	Probe(path string) (*MediaInfo, error)
+	// Decode 解码音频，不支持时返回包装了 ErrUnsupported 的错误
+	Decode(path string, options DecodeOptions) (*AudioData, error)
+	// Encode 编码音频并写入文件，不支持时返回包装了 ErrUnsupported 的错误
+	Encode(audio *AudioData, path string, format string, options ExportOptions) error
+}
+
+// MultiStreamDecoder 可一次解码多个音频流的后端（可选接口），
+// 未实现时 LoadAllAudioStreams 逐个解码
+type MultiStreamDecoder interface {
+	DecodeStreams(path string, streams []StreamInfo) ([]*AudioData, error)
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+// RegisterBackend 为格式（文件扩展名，如 "wav"）注册后端，backend 为 nil 时取消注册
+func RegisterBackend(format string, backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	format = normalizeFormat(format)
+	if backend == nil {
+		delete(backends, format)
+		return
+	}
+	backends[format] = backend
+}
+
+// BackendFor 返回格式对应的后端，未注册的格式使用 FFmpeg
+func BackendFor(format string) Backend {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	if backend, ok := backends[normalizeFormat(format)]; ok {
+		return backend
+	}
+	return FFmpeg
+}
+
+// normalizeFormat 统一格式名（小写、去掉前导点）
+func normalizeFormat(format string) string {
+	return strings.ToLower(strings.TrimPrefix(format, "."))
+}
+
+// formatOf 返回路径的扩展名作为格式
+func formatOf(path string) string {
+	return normalizeFormat(filepath.Ext(path))
+}
+
+// decode 用格式对应的后端解码，后端不支持时回退到 ffmpeg
+func decode(path string, format string, options DecodeOptions) (*AudioData, error) {
+	backend := BackendFor(format)
+	audio, err := backend.Decode(path, options)
+	if errors.Is(err, ErrUnsupported) && backend != Backend(FFmpeg) {
+		return FFmpeg.Decode(path, options)
+	}
+	return audio, err
+}
+
+// encode 用格式对应的后端编码，后端不支持时回退到 ffmpeg
+func encode(audio *AudioData, path string, format string, options ExportOptions) error {
+	backend := BackendFor(format)
+	err := backend.Encode(audio, path, format, options)
+	if errors.Is(err, ErrUnsupported) && backend != Backend(FFmpeg) {
+		return FFmpeg.Encode(audio, path, format, options)
+	}
+	return err
+}
+
+// probe 用格式对应的后端读取媒体信息，后端不支持时回退到 ffprobe
+func probe(path string, format string) (*MediaInfo, error) {
+	backend := BackendFor(format)
+	info, err := backend.Probe(path)
+	if errors.Is(err, ErrUnsupported) && backend != Backend(FFmpeg) {
+		return FFmpeg.Probe(path)
+	}
+	return info, err
+}
+
+// unsupported 返回包装了 ErrUnsupported 的错误
+func unsupported(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrUnsupported)
+}
@@ -1,282 +1,59 @@
 package converter
 
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strconv"
-)
+import "fmt"
 
 // AudioData 音频数据结构
 type AudioData struct {
-	Samples    []float64 // 音频样本数据
-	SampleRate int       // 采样率
-	Channels   int       // 声道数
-	BitDepth   int       // 位深度
+	Samples     []float64         // 音频样本数据
+	SampleRate  int               // 采样率
+	Channels    int               // 声道数
+	BitDepth    int               // 位深度
+	ChannelMask uint32            // 声道掩码（WAV dwChannelMask），0 表示默认布局
+	Tags        map[string]string // 元数据标签（标签名为小写的通用名称，如 title、artist）
 }
 
 // FFProbeOutput ffprobe输出的JSON结构
+//
+// Deprecated: 使用 Probe 返回的 MediaInfo
 type FFProbeOutput struct {
 	Streams []struct {
-		CodecType  string `json:"codec_type"`
-		SampleRate string `json:"sample_rate"`
-		Channels   int    `json:"channels"`
-		BitsPerRaw string `json:"bits_per_raw_sample"`
+		CodecType     string `json:"codec_type"`
+		SampleRate    string `json:"sample_rate"`
+		Channels      int    `json:"channels"`
+		BitsPerRaw    string `json:"bits_per_raw_sample"`
+		ChannelLayout string `json:"channel_layout"`
 	} `json:"streams"`
 }
 
-// LoadAudioFile 从文件加载音频数据
+// LoadAudioFile 从文件加载音频数据，使用格式对应的后端解码（未注册的格式使用 ffmpeg）
 func LoadAudioFile(path string, format string) (*AudioData, error) {
-	// 创建临时WAV文件
-	tempDir, err := os.MkdirTemp("", "goudub")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	wavPath := filepath.Join(tempDir, "temp.wav")
-	var converted bool
-
-	// 首先获取源文件信息
-	infoCmd := exec.Command("ffprobe", "-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", path)
-	output, err := infoCmd.Output()
-	if err == nil {
-		var probeData FFProbeOutput
-		if err := json.Unmarshal(output, &probeData); err == nil {
-			for _, stream := range probeData.Streams {
-				if stream.CodecType == "audio" && stream.BitsPerRaw != "" {
-					if bitDepth, err := strconv.Atoi(stream.BitsPerRaw); err == nil {
-						// 使用源文件的位深度
-						cmd := exec.Command("ffmpeg", "-i", path,
-							"-acodec", fmt.Sprintf("pcm_s%dle", bitDepth),
-							"-f", "wav",
-							wavPath)
-						if err := cmd.Run(); err == nil {
-							converted = true
-							break
-						}
-					}
-				}
-			}
-		}
-	}
-
-	// 如果无法获取源文件信息或转换失败，使用默认设置
-	if !converted {
-		cmd := exec.Command("ffmpeg", "-i", path,
-			"-acodec", "pcm_s16le",
-			"-f", "wav",
-			wavPath)
-		if err := cmd.Run(); err != nil {
-			return nil, fmt.Errorf("failed to convert audio to wav: %w", err)
-		}
-	}
-
-	// 读取WAV文件信息
-	infoCmd = exec.Command("ffprobe", "-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", wavPath)
-	output, err = infoCmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get audio info: %w", err)
-	}
-
-	// 解析ffprobe输出的JSON数据
-	var probeData FFProbeOutput
-	if err := json.Unmarshal(output, &probeData); err != nil {
-		return nil, fmt.Errorf("failed to parse audio info: %w", err)
-	}
-
-	// 获取音频流信息
-	var audioStream *struct {
-		CodecType  string `json:"codec_type"`
-		SampleRate string `json:"sample_rate"`
-		Channels   int    `json:"channels"`
-		BitsPerRaw string `json:"bits_per_raw_sample"`
-	}
-
-	for i := range probeData.Streams {
-		if probeData.Streams[i].CodecType == "audio" {
-			audioStream = &probeData.Streams[i]
-			break
-		}
-	}
-
-	if audioStream == nil {
-		return nil, fmt.Errorf("no audio stream found")
-	}
-
-	// 解析音频参数
-	sampleRate, err := strconv.Atoi(audioStream.SampleRate)
-	if err != nil {
-		sampleRate = 44100 // 默认采样率
-	}
-
-	channels := audioStream.Channels
-	if channels <= 0 {
-		channels = 2 // 默认声道数
-	}
-
-	bitDepth := 16 // 默认位深度
-	if audioStream.BitsPerRaw != "" {
-		if bd, err := strconv.Atoi(audioStream.BitsPerRaw); err == nil {
-			bitDepth = bd
-		}
-	}
-
-	// 读取WAV文件数据
-	data, err := os.ReadFile(wavPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read wav file: %w", err)
-	}
-
-	// 计算每个样本的字节数
-	bytesPerSample := bitDepth / 8
-	samples := make([]float64, len(data)/bytesPerSample)
-
-	// 将字节数据转换为float64样本
-	for i := 0; i < len(samples); i++ {
-		var sample int32
-		switch bitDepth {
-		case 8:
-			sample = int32(data[i]) - 128
-			samples[i] = float64(sample) / 128.0
-		case 16:
-			sample = int32(int16(data[i*2]) | int16(data[i*2+1])<<8)
-			samples[i] = float64(sample) / 32768.0
-		case 24:
-			sample = int32(data[i*3]) | int32(data[i*3+1])<<8 | int32(data[i*3+2])<<16
-			if sample&0x800000 != 0 {
-				sample |= ^0xffffff
-			}
-			samples[i] = float64(sample) / 8388608.0
-		case 32:
-			sample = int32(data[i*4]) | int32(data[i*4+1])<<8 | int32(data[i*4+2])<<16 | int32(data[i*4+3])<<24
-			samples[i] = float64(sample) / 2147483648.0
-		default:
-			return nil, fmt.Errorf("unsupported bit depth: %d", bitDepth)
-		}
-	}
-
-	return &AudioData{
-		Samples:    samples,
-		SampleRate: sampleRate,
-		Channels:   channels,
-		BitDepth:   bitDepth,
-	}, nil
+	return decode(path, format, DecodeOptions{})
 }
 
 // LoadAudioFileWithParams 从文件加载音频数据，并按指定参数转换
 func LoadAudioFileWithParams(path string, format string, targetRate int, targetChannels int, targetDepth int) (*AudioData, error) {
-	// 创建临时WAV文件
-	tempDir, err := os.MkdirTemp("", "goudub")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	wavPath := filepath.Join(tempDir, "temp.wav")
-
-	// 使用 ffmpeg 转换音频文件，并进行重采样
-	cmd := exec.Command("ffmpeg", "-i", path,
-		"-ar", strconv.Itoa(targetRate), // 设置目标采样率
-		"-ac", strconv.Itoa(targetChannels), // 设置目标声道数
-		"-acodec", fmt.Sprintf("pcm_s%dle", targetDepth), // 设置目标位深度
-		"-f", "wav",
-		wavPath)
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to convert audio to wav: %w", err)
-	}
-
-	// 读取WAV文件信息
-	infoCmd := exec.Command("ffprobe", "-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", wavPath)
-	output, err := infoCmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get audio info: %w", err)
-	}
-
-	// 解析ffprobe输出的JSON数据
-	var probeData FFProbeOutput
-	if err := json.Unmarshal(output, &probeData); err != nil {
-		return nil, fmt.Errorf("failed to parse audio info: %w", err)
-	}
-
-	// 获取音频流信息
-	var audioStream *struct {
-		CodecType  string `json:"codec_type"`
-		SampleRate string `json:"sample_rate"`
-		Channels   int    `json:"channels"`
-		BitsPerRaw string `json:"bits_per_raw_sample"`
-	}
-
-	for i := range probeData.Streams {
-		if probeData.Streams[i].CodecType == "audio" {
-			audioStream = &probeData.Streams[i]
-			break
-		}
-	}
-
-	if audioStream == nil {
-		return nil, fmt.Errorf("no audio stream found")
-	}
-
-	// 解析音频参数
-	sampleRate, err := strconv.Atoi(audioStream.SampleRate)
-	if err != nil {
-		sampleRate = targetRate // 使用目标采样率
-	}
-
-	channels := audioStream.Channels
-	if channels <= 0 {
-		channels = targetChannels // 使用目标声道数
-	}
-
-	bitDepth := targetDepth // 使用目标位深度
-
-	// 读取WAV文件数据
-	data, err := os.ReadFile(wavPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read wav file: %w", err)
-	}
-
-	// 计算每个样本的字节数
-	bytesPerSample := bitDepth / 8
-	samples := make([]float64, len(data)/bytesPerSample)
-
-	// 将字节数据转换为float64样本
-	for i := 0; i < len(samples); i++ {
-		var sample int32
-		switch bitDepth {
-		case 8:
-			sample = int32(data[i]) - 128
-			samples[i] = float64(sample) / 128.0
-		case 16:
-			sample = int32(int16(data[i*2]) | int16(data[i*2+1])<<8)
-			samples[i] = float64(sample) / 32768.0
-		case 24:
-			sample = int32(data[i*3]) | int32(data[i*3+1])<<8 | int32(data[i*3+2])<<16
-			if sample&0x800000 != 0 {
-				sample |= ^0xffffff
-			}
-			samples[i] = float64(sample) / 8388608.0
-		case 32:
-			sample = int32(data[i*4]) | int32(data[i*4+1])<<8 | int32(data[i*4+2])<<16 | int32(data[i*4+3])<<24
-			samples[i] = float64(sample) / 2147483648.0
-		default:
-			return nil, fmt.Errorf("unsupported bit depth: %d", bitDepth)
-		}
-	}
+	return decode(path, format, DecodeOptions{
+		SampleRate: targetRate,
+		Channels:   targetChannels,
+		BitDepth:   targetDepth,
+	})
+}
 
-	return &AudioData{
-		Samples:    samples,
-		SampleRate: sampleRate,
-		Channels:   channels,
-		BitDepth:   bitDepth,
-	}, nil
+// ExportOptions 导出选项
+type ExportOptions struct {
+	Dither       DitherType        // 降低位深度时使用的抖动类型
+	NoiseShaping NoiseShaping      // 噪声整形方式
+	Tags         map[string]string // 覆盖或追加的元数据标签，值为空字符串时删除该标签
+	CoverArt     string            // 封面图片路径（仅支持 mp3 和 m4a）
 }
 
 // SaveAudioFile 将音频数据保存到文件
 func SaveAudioFile(audio *AudioData, path string, format string) error {
+	return SaveAudioFileWithOptions(audio, path, format, ExportOptions{})
+}
+
+// SaveAudioFileWithOptions 按指定导出选项将音频数据保存到文件
+func SaveAudioFileWithOptions(audio *AudioData, path string, format string, options ExportOptions) error {
 	// 验证位深度
 	switch audio.BitDepth {
 	case 8, 16, 24, 32:
@@ -285,87 +62,5 @@ func SaveAudioFile(audio *AudioData, path string, format string) error {
 		return fmt.Errorf("unsupported bit depth: %d", audio.BitDepth)
 	}
 
-	// 创建临时WAV文件
-	tempDir, err := os.MkdirTemp("", "goudub")
-	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	wavPath := filepath.Join(tempDir, "temp.wav")
-
-	// 创建WAV文件
-	f, err := os.Create(wavPath)
-	if err != nil {
-		return fmt.Errorf("failed to create wav file: %w", err)
-	}
-	defer f.Close()
-
-	// 计算数据大小
-	dataSize := len(audio.Samples) * audio.BitDepth / 8
-
-	// 写入WAV文件头
-	if err := writeWAVHeader(f, audio.SampleRate, audio.Channels, audio.BitDepth, dataSize); err != nil {
-		return fmt.Errorf("failed to write wav header: %w", err)
-	}
-
-	// 写入音频数据
-	if err := writeWAVData(f, audio.Samples, audio.BitDepth); err != nil {
-		return fmt.Errorf("failed to write wav data: %w", err)
-	}
-
-	// 确保文件已完全写入
-	if err := f.Sync(); err != nil {
-		return fmt.Errorf("failed to sync wav file: %w", err)
-	}
-
-	// 如果目标格式是WAV，直接复制文件
-	if format == "wav" {
-		data, err := os.ReadFile(wavPath)
-		if err != nil {
-			return fmt.Errorf("failed to read wav file: %w", err)
-		}
-		if err := os.WriteFile(path, data, 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
-		}
-		return nil
-	}
-
-	// 根据格式选择适当的编码器和参数
-	var cmd *exec.Cmd
-	switch format {
-	case "mp3":
-		cmd = exec.Command("ffmpeg", "-y",
-			"-f", "wav",
-			"-i", wavPath,
-			"-c:a", "libmp3lame",
-			"-b:a", "320k", // 使用高比特率
-			"-ar", strconv.Itoa(audio.SampleRate),
-			"-ac", strconv.Itoa(audio.Channels),
-			path)
-	case "ogg":
-		cmd = exec.Command("ffmpeg", "-y",
-			"-f", "wav",
-			"-i", wavPath,
-			"-c:a", "libvorbis",
-			"-q:a", "10", // 使用高质量设置
-			"-ar", strconv.Itoa(audio.SampleRate),
-			"-ac", strconv.Itoa(audio.Channels),
-			path)
-	default:
-		cmd = exec.Command("ffmpeg", "-y",
-			"-f", "wav",
-			"-i", wavPath,
-			"-acodec", "pcm_s"+strconv.Itoa(audio.BitDepth)+"le",
-			"-ar", strconv.Itoa(audio.SampleRate),
-			"-ac", strconv.Itoa(audio.Channels),
-			"-f", format,
-			path)
-	}
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to convert wav to %s: %w", format, err)
-	}
-
-	return nil
+	return encode(audio, path, normalizeFormat(format), options)
 }
@@ -1,9 +1,12 @@
 package converter
 
 import (
+	"bytes"
+	"encoding/binary"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadAudioFile(t *testing.T) {
@@ -316,9 +319,489 @@ func TestOggToWavConversion(t *testing.T) {
 	}
 }
 
+func TestQuantizer(t *testing.T) {
+	tests := []struct {
+		name     string
+		bitDepth int
+		dither   DitherType
+		shaping  NoiseShaping
+	}{
+		{name: "16-bit No Dither", bitDepth: 16, dither: DitherNone, shaping: NoiseShapingNone},
+		{name: "16-bit Rectangular", bitDepth: 16, dither: DitherRectangular, shaping: NoiseShapingNone},
+		{name: "16-bit TPDF", bitDepth: 16, dither: DitherTriangular, shaping: NoiseShapingNone},
+		{name: "16-bit TPDF First Order", bitDepth: 16, dither: DitherTriangular, shaping: NoiseShapingFirstOrder},
+		{name: "24-bit TPDF Lipshitz", bitDepth: 24, dither: DitherTriangular, shaping: NoiseShapingLipshitz},
+		{name: "8-bit TPDF", bitDepth: 8, dither: DitherTriangular, shaping: NoiseShapingNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quantizer := NewQuantizer(tt.bitDepth, 1, tt.dither, tt.shaping)
+			scale := float64(int64(1)<<(tt.bitDepth-1)) - 1
+
+			// 超出满幅的样本必须被限幅而不是回绕
+			if v := quantizer.Quantize(1.5); float64(v) != scale {
+				t.Errorf("expected positive over to clamp to %f, got %d", scale, v)
+			}
+			if v := quantizer.Quantize(-1.5); float64(v) != -scale-1 {
+				t.Errorf("expected negative over to clamp to %f, got %d", -scale-1, v)
+			}
+
+			// 长时间平均后量化误差应接近0（抖动无偏）
+			quantizer = NewQuantizer(tt.bitDepth, 1, tt.dither, tt.shaping)
+			var sum float64
+			const n = 20000
+			for i := 0; i < n; i++ {
+				sample := 0.3 + 0.1*float64(i%7)/7
+				sum += float64(quantizer.Quantize(sample)) - sample*scale
+			}
+			if mean := sum / n; mean > 0.1 || mean < -0.1 {
+				t.Errorf("expected unbiased quantization, mean error %f LSB", mean)
+			}
+		})
+	}
+}
+
+func TestWriteWAVDataClamps(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeWAVData(&buf, []float64{2.0, -2.0}, 1, 16, DitherNone, NoiseShapingNone); err != nil {
+		t.Fatalf("failed to write wav data: %v", err)
+	}
+
+	data := buf.Bytes()
+	if v := int16(binary.LittleEndian.Uint16(data[0:2])); v != 32767 {
+		t.Errorf("expected 32767, got %d", v)
+	}
+	if v := int16(binary.LittleEndian.Uint16(data[2:4])); v != -32768 {
+		t.Errorf("expected -32768, got %d", v)
+	}
+}
+
+func TestWriteWAVHeaderExtensible(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeWAVHeader(&buf, 48000, 6, 24, 0, 0x60f, 0); err != nil {
+		t.Fatalf("failed to write wav header: %v", err)
+	}
+
+	data := buf.Bytes()
+	if len(data) != 68 {
+		t.Fatalf("expected 68-byte extensible header, got %d", len(data))
+	}
+	if format := binary.LittleEndian.Uint16(data[20:22]); format != 0xfffe {
+		t.Errorf("expected WAVE_FORMAT_EXTENSIBLE, got %#x", format)
+	}
+	if mask := binary.LittleEndian.Uint32(data[40:44]); mask != 0x60f {
+		t.Errorf("expected channel mask 0x60f, got %#x", mask)
+	}
+
+	buf.Reset()
+	if err := writeWAVHeader(&buf, 44100, 2, 16, 0, 0, 0); err != nil {
+		t.Fatalf("failed to write wav header: %v", err)
+	}
+	if buf.Len() != 44 {
+		t.Errorf("expected 44-byte PCM header for plain stereo, got %d", buf.Len())
+	}
+}
+
 func abs(x int) int {
 	if x < 0 {
 		return -x
 	}
 	return x
 }
+
+// 多音轨 mkv 的 ffprobe 输出（节选）
+const sampleProbeOutput = `{
+	"streams": [
+		{"index": 0, "codec_type": "video", "codec_name": "h264", "profile": "High", "width": 1920, "height": 1080,
+		 "disposition": {"default": 1}, "tags": {"language": "und"}},
+		{"index": 1, "codec_type": "audio", "codec_name": "aac", "codec_long_name": "AAC (Advanced Audio Coding)",
+		 "profile": "LC", "sample_fmt": "fltp", "sample_rate": "48000", "channels": 6, "channel_layout": "5.1",
+		 "bits_per_sample": 0, "bit_rate": "384000", "start_time": "0.000000",
+		 "disposition": {"default": 1, "dub": 0}, "tags": {"language": "eng", "title": "Surround"}},
+		{"index": 2, "codec_type": "audio", "codec_name": "flac", "sample_fmt": "s32", "sample_rate": "48000",
+		 "channels": 2, "channel_layout": "stereo", "bits_per_raw_sample": "24",
+		 "disposition": {"default": 0, "comment": 1}, "tags": {"LANGUAGE": "fre", "TITLE": "Commentaire"}}
+	],
+	"chapters": [
+		{"id": 1, "start_time": "0.000000", "end_time": "90.500000", "tags": {"title": "Opening"}},
+		{"id": 2, "start_time": "90.500000", "end_time": "600.000000", "tags": {"title": "Act 1"}}
+	],
+	"format": {
+		"format_name": "matroska,webm", "format_long_name": "Matroska / WebM", "start_time": "0.000000",
+		"duration": "600.000000", "size": "123456789", "bit_rate": "1646090", "tags": {"title": "Feature"}
+	}
+}`
+
+func TestParseMediaInfo(t *testing.T) {
+	info, err := parseMediaInfo([]byte(sampleProbeOutput))
+	if err != nil {
+		t.Fatalf("failed to parse probe output: %v", err)
+	}
+
+	if info.Format != "matroska,webm" || info.Duration != 600*time.Second || info.BitRate != 1646090 || info.Size != 123456789 {
+		t.Errorf("unexpected format info: %+v", info)
+	}
+	if info.Tag("TITLE") != "Feature" {
+		t.Errorf("expected title Feature, got %q", info.Tag("TITLE"))
+	}
+	if len(info.Streams) != 3 {
+		t.Fatalf("expected 3 streams, got %d", len(info.Streams))
+	}
+	if video := info.Streams[0]; video.Width != 1920 || video.Height != 1080 || video.Profile != "High" {
+		t.Errorf("unexpected video stream: %+v", video)
+	}
+
+	audioStreams := info.AudioStreams()
+	if len(audioStreams) != 2 {
+		t.Fatalf("expected 2 audio streams, got %d", len(audioStreams))
+	}
+	surround := audioStreams[0]
+	if surround.Index != 1 || surround.SampleRate != 48000 || surround.Channels != 6 || surround.ChannelLayout != "5.1" ||
+		surround.SampleFormat != "fltp" || surround.BitRate != 384000 || surround.Language != "eng" || surround.Title != "Surround" ||
+		!surround.Disposition.Default {
+		t.Errorf("unexpected surround stream: %+v", surround)
+	}
+	commentary := audioStreams[1]
+	if commentary.BitsPerSample != 24 || commentary.Language != "fre" || commentary.Title != "Commentaire" ||
+		commentary.Disposition.Default || !commentary.Disposition.Comment {
+		t.Errorf("unexpected commentary stream: %+v", commentary)
+	}
+
+	if len(info.Chapters) != 2 {
+		t.Fatalf("expected 2 chapters, got %d", len(info.Chapters))
+	}
+	if chapter := info.Chapters[1]; chapter.Title != "Act 1" || chapter.Start != 90500*time.Millisecond || chapter.End != 600*time.Second {
+		t.Errorf("unexpected chapter: %+v", chapter)
+	}
+
+	if _, err := parseMediaInfo([]byte("not json")); err == nil {
+		t.Error("expected error for invalid probe output")
+	}
+}
+
+func TestSelectAudioStream(t *testing.T) {
+	info, err := parseMediaInfo([]byte(sampleProbeOutput))
+	if err != nil {
+		t.Fatalf("failed to parse probe output: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		options LoadOptions
+		index   int
+		wantErr bool
+	}{
+		{"default", LoadOptions{}, 1, false},
+		{"by index", LoadOptions{Index: 1}, 2, false},
+		{"by language", LoadOptions{Language: "FRE"}, 2, false},
+		{"by title", LoadOptions{Title: "comment"}, 2, false},
+		{"language and title", LoadOptions{Language: "eng", Title: "surround"}, 1, false},
+		{"custom", LoadOptions{Select: func(s StreamInfo) bool { return s.Disposition.Comment }}, 2, false},
+		{"no match", LoadOptions{Language: "ger"}, 0, true},
+		{"index out of range", LoadOptions{Language: "eng", Index: 1}, 0, true},
+		{"negative index", LoadOptions{Index: -1}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := info.SelectAudioStream(tt.options)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got stream %d", stream.Index)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to select stream: %v", err)
+			}
+			if stream.Index != tt.index {
+				t.Errorf("expected stream %d, got %d", tt.index, stream.Index)
+			}
+		})
+	}
+
+	tags := streamTags(info, info.Streams[2])
+	if tags["title"] != "Commentaire" || tags["language"] != "fre" {
+		t.Errorf("unexpected stream tags: %v", tags)
+	}
+	if bits := decodeBitDepth(info.Streams[2]); bits != 24 {
+		t.Errorf("expected 24-bit decode, got %d", bits)
+	}
+}
+
+func TestSaveWAVWithTags(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "tagged.wav")
+
+	audio := &AudioData{
+		Samples:    []float64{0, 0.25, -0.25},
+		SampleRate: 8000,
+		Channels:   1,
+		BitDepth:   8, // 3 字节数据，需要补齐到偶数
+		Tags:       map[string]string{"Title": "Scene 12", "artist": "Studio", "custom": "dropped"},
+	}
+	options := ExportOptions{Tags: map[string]string{"artist": "", "comment": "take 3"}}
+	if err := SaveAudioFileWithOptions(audio, path, "wav", options); err != nil {
+		t.Fatalf("failed to save wav file: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read wav file: %v", err)
+	}
+	if size := binary.LittleEndian.Uint32(data[4:8]); int(size) != len(data)-8 {
+		t.Errorf("expected RIFF size %d, got %d", len(data)-8, size)
+	}
+
+	// 数据块之后是补齐字节和 LIST/INFO 块
+	list := data[44+3+1:]
+	if string(list[0:4]) != "LIST" || string(list[8:12]) != "INFO" {
+		t.Fatalf("expected LIST/INFO chunk, got %q", list[:12])
+	}
+	if size := binary.LittleEndian.Uint32(list[4:8]); int(size) != len(list)-8 {
+		t.Errorf("expected LIST size %d, got %d", len(list)-8, size)
+	}
+	if !bytes.Contains(list, []byte("INAM\x09\x00\x00\x00Scene 12\x00")) {
+		t.Errorf("expected INAM title sub-chunk in %q", list)
+	}
+	if !bytes.Contains(list, []byte("ICMT\x07\x00\x00\x00take 3\x00\x00")) {
+		t.Errorf("expected padded ICMT comment sub-chunk in %q", list)
+	}
+	if bytes.Contains(list, []byte("IART")) || bytes.Contains(list, []byte("dropped")) {
+		t.Errorf("expected removed and unknown tags to be skipped, got %q", list)
+	}
+
+	// 封面图片仅支持 mp3 和 m4a
+	if err := SaveAudioFileWithOptions(audio, path, "wav", ExportOptions{CoverArt: "cover.jpg"}); err == nil {
+		t.Error("expected error for cover art on wav")
+	}
+}
+
+func TestCollectTags(t *testing.T) {
+	info, err := parseMediaInfo([]byte(sampleProbeOutput))
+	if err != nil {
+		t.Fatalf("failed to parse probe output: %v", err)
+	}
+
+	tags := collectTags(info)
+	// 容器级标题覆盖流标题，技术性的 language 标签被忽略
+	if tags["title"] != "Feature" {
+		t.Errorf("expected title Feature, got %q", tags["title"])
+	}
+	if _, ok := tags["language"]; ok {
+		t.Errorf("expected language tag to be skipped, got %v", tags)
+	}
+
+	args := metadataArgs(map[string]string{"title": "A", "artist": "B"})
+	expected := []string{"-metadata", "artist=B", "-metadata", "title=A"}
+	if len(args) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, args)
+	}
+	for i := range args {
+		if args[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, args)
+			break
+		}
+	}
+}
+
+func TestLoadWAVRange(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "surround.wav")
+
+	// 5.1(side)，24 位，采样率 1000Hz，1 秒
+	const channels, frames = 6, 1000
+	samples := make([]float64, channels*frames)
+	for i := range samples {
+		samples[i] = float64(i%frames)/frames - 0.5
+	}
+	audio := &AudioData{
+		Samples:     samples,
+		SampleRate:  1000,
+		Channels:    channels,
+		BitDepth:    24,
+		ChannelMask: 0x60f,
+		Tags:        map[string]string{"title": "Reel 3"},
+	}
+	if err := SaveAudioFile(audio, path, "wav"); err != nil {
+		t.Fatalf("failed to save wav file: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		start      time.Duration
+		duration   time.Duration
+		firstFrame int
+		frames     int
+	}{
+		{name: "Middle", start: 100 * time.Millisecond, duration: 250 * time.Millisecond, firstFrame: 100, frames: 250},
+		{name: "To End", start: 900 * time.Millisecond, duration: 0, firstFrame: 900, frames: 100},
+		{name: "Past End", start: 950 * time.Millisecond, duration: time.Second, firstFrame: 950, frames: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := LoadAudioFileRange(path, "wav", tt.start, tt.duration)
+			if err != nil {
+				t.Fatalf("failed to load wav range: %v", err)
+			}
+			if loaded.Channels != channels || loaded.BitDepth != 24 || loaded.ChannelMask != 0x60f {
+				t.Errorf("unexpected format: %d channels, %d bits, mask %#x", loaded.Channels, loaded.BitDepth, loaded.ChannelMask)
+			}
+			if loaded.Tags["title"] != "Reel 3" {
+				t.Errorf("expected title Reel 3, got %v", loaded.Tags)
+			}
+			if len(loaded.Samples) != tt.frames*channels {
+				t.Fatalf("expected %d samples, got %d", tt.frames*channels, len(loaded.Samples))
+			}
+			for i, sample := range loaded.Samples {
+				expected := samples[tt.firstFrame*channels+i]
+				if diff := sample - expected; diff > 1e-6 || diff < -1e-6 {
+					t.Fatalf("sample %d: expected %f, got %f", i, expected, sample)
+				}
+			}
+		})
+	}
+
+	if _, err := LoadAudioFileRange(path, "wav", 2*time.Second, 0); err == nil {
+		t.Error("expected error for start beyond end of file")
+	}
+}
+
+// fakeBackend 记录调用的测试后端
+type fakeBackend struct {
+	decoded []DecodeOptions
+	encoded []string
+}
+
+func (b *fakeBackend) Name() string { return "fake" }
+
+func (b *fakeBackend) Probe(path string) (*MediaInfo, error) {
+	return &MediaInfo{Format: "fake", Streams: []StreamInfo{{CodecType: "audio", SampleRate: 8000, Channels: 1}}}, nil
+}
+
+func (b *fakeBackend) Decode(path string, options DecodeOptions) (*AudioData, error) {
+	b.decoded = append(b.decoded, options)
+	return &AudioData{Samples: []float64{0.5}, SampleRate: 8000, Channels: 1, BitDepth: 16}, nil
+}
+
+func (b *fakeBackend) Encode(audio *AudioData, path string, format string, options ExportOptions) error {
+	b.encoded = append(b.encoded, format)
+	return nil
+}
+
+func TestBackendRegistry(t *testing.T) {
+	if _, ok := BackendFor(".WAV").(WAVBackend); !ok {
+		t.Errorf("expected wav backend, got %s", BackendFor(".WAV").Name())
+	}
+	if _, ok := BackendFor("aif").(AIFFBackend); !ok {
+		t.Errorf("expected aiff backend, got %s", BackendFor("aif").Name())
+	}
+	if BackendFor("mp3") != Backend(FFmpeg) {
+		t.Errorf("expected ffmpeg backend for mp3, got %s", BackendFor("mp3").Name())
+	}
+
+	fake := &fakeBackend{}
+	RegisterBackend("fake", fake)
+	defer RegisterBackend("fake", nil)
+
+	audio, err := LoadAudioFileWithParams("input.fake", "fake", 8000, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to load with fake backend: %v", err)
+	}
+	if len(audio.Samples) != 1 || len(fake.decoded) != 1 || fake.decoded[0].SampleRate != 8000 {
+		t.Errorf("unexpected decode calls: %+v", fake.decoded)
+	}
+	if _, err := LoadAudioFileRange("input.fake", "fake", time.Second, 2*time.Second); err != nil {
+		t.Fatalf("failed to load range with fake backend: %v", err)
+	}
+	if options := fake.decoded[1]; options.Start != time.Second || options.Duration != 2*time.Second {
+		t.Errorf("unexpected range options: %+v", options)
+	}
+	if err := SaveAudioFile(audio, "output.fake", "FAKE"); err != nil {
+		t.Fatalf("failed to save with fake backend: %v", err)
+	}
+	if len(fake.encoded) != 1 || fake.encoded[0] != "fake" {
+		t.Errorf("unexpected encode calls: %v", fake.encoded)
+	}
+	if info, err := Probe("input.fake"); err != nil || info.Format != "fake" {
+		t.Errorf("expected fake probe, got %+v, %v", info, err)
+	}
+
+	RegisterBackend("fake", nil)
+	if BackendFor("fake") != Backend(FFmpeg) {
+		t.Error("expected ffmpeg backend after unregistering")
+	}
+}
+
+func TestNativeBackends(t *testing.T) {
+	tempDir := t.TempDir()
+
+	samples := make([]float64, 2*1000)
+	for i := range samples {
+		samples[i] = float64(i%200-100) / 128
+	}
+	audio := &AudioData{
+		Samples:    samples,
+		SampleRate: 44100,
+		Channels:   2,
+		BitDepth:   24,
+		Tags:       map[string]string{"title": "Take 7", "artist": "Studio"},
+	}
+
+	for _, format := range []string{"wav", "aiff"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(tempDir, "native."+format)
+			if err := SaveAudioFile(audio, path, format); err != nil {
+				t.Fatalf("failed to save %s: %v", format, err)
+			}
+
+			loaded, err := LoadAudioFile(path, format)
+			if err != nil {
+				t.Fatalf("failed to load %s: %v", format, err)
+			}
+			if loaded.SampleRate != 44100 || loaded.Channels != 2 || loaded.BitDepth != 24 || len(loaded.Samples) != len(samples) {
+				t.Fatalf("unexpected format: %d Hz, %d channels, %d bits, %d samples",
+					loaded.SampleRate, loaded.Channels, loaded.BitDepth, len(loaded.Samples))
+			}
+			for i := range samples {
+				if diff := loaded.Samples[i] - samples[i]; diff > 1e-6 || diff < -1e-6 {
+					t.Fatalf("sample %d: expected %f, got %f", i, samples[i], loaded.Samples[i])
+				}
+			}
+			if loaded.Tags["title"] != "Take 7" || loaded.Tags["artist"] != "Studio" {
+				t.Errorf("unexpected tags: %v", loaded.Tags)
+			}
+
+			info, err := Probe(path)
+			if err != nil {
+				t.Fatalf("failed to probe %s: %v", format, err)
+			}
+			if streams := info.AudioStreams(); len(streams) != 1 || streams[0].SampleRate != 44100 || streams[0].BitsPerSample != 24 {
+				t.Errorf("unexpected streams: %+v", info.Streams)
+			}
+
+			part, err := LoadAudioFileRange(path, format, 10*time.Millisecond, 5*time.Millisecond)
+			if err != nil {
+				t.Fatalf("failed to load range: %v", err)
+			}
+			// 441 帧起的 220 帧
+			if len(part.Samples) != 2*220 || part.Samples[0] != loaded.Samples[2*441] {
+				t.Errorf("unexpected range: %d samples", len(part.Samples))
+			}
+
+			if _, err := LoadAudioFileWithOptions(path, format, LoadOptions{Index: 1}); err == nil {
+				t.Error("expected error for missing second stream")
+			}
+		})
+	}
+
+	rate := encodeExtended(44100)
+	if !bytes.Equal(rate, []byte{0x40, 0x0e, 0xac, 0x44, 0, 0, 0, 0, 0, 0}) {
+		t.Errorf("unexpected extended encoding: % x", rate)
+	}
+	if decodeExtended(rate) != 44100 {
+		t.Errorf("expected 44100, got %f", decodeExtended(rate))
+	}
+}
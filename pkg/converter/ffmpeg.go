@@ -0,0 +1,252 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// FFmpegBackend 调用 ffmpeg/ffprobe 可执行文件的后端，支持 ffmpeg 能处理的所有格式
+type FFmpegBackend struct {
+	FFmpegPath  string // ffmpeg 可执行文件路径
+	FFprobePath string // ffprobe 可执行文件路径
+}
+
+// FFmpeg 默认后端，修改其路径即可使用自定义的 ffmpeg（类似 pydub 的 AudioSegment.converter）
+var FFmpeg = NewFFmpegBackend("ffmpeg", "ffprobe")
+
+// NewFFmpegBackend 创建使用指定可执行文件的 ffmpeg 后端
+func NewFFmpegBackend(ffmpegPath, ffprobePath string) *FFmpegBackend {
+	return &FFmpegBackend{
+		FFmpegPath:  ffmpegPath,
+		FFprobePath: ffprobePath,
+	}
+}
+
+// Name 实现 Backend 接口
+func (b *FFmpegBackend) Name() string {
+	return "ffmpeg"
+}
+
+// Available 检查 ffmpeg 和 ffprobe 是否可用
+func (b *FFmpegBackend) Available() bool {
+	if _, err := exec.LookPath(b.FFmpegPath); err != nil {
+		return false
+	}
+	_, err := exec.LookPath(b.FFprobePath)
+	return err == nil
+}
+
+// Command 创建 ffmpeg 命令
+func (b *FFmpegBackend) Command(args ...string) *exec.Cmd {
+	return exec.Command(b.FFmpegPath, args...)
+}
+
+// ProbeCommand 创建 ffprobe 命令
+func (b *FFmpegBackend) ProbeCommand(args ...string) *exec.Cmd {
+	return exec.Command(b.FFprobePath, args...)
+}
+
+// Probe 实现 Backend 接口
+func (b *FFmpegBackend) Probe(path string) (*MediaInfo, error) {
+	output, err := b.ProbeCommand(append(probeArgs, path)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe %s: %w", path, err)
+	}
+	return parseMediaInfo(output)
+}
+
+// ProbeReader 从数据流读取媒体信息（通过标准输入传给 ffprobe）
+func (b *FFmpegBackend) ProbeReader(r io.Reader) (*MediaInfo, error) {
+	cmd := b.ProbeCommand(append(probeArgs, "-i", "pipe:0")...)
+	cmd.Stdin = r
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to probe input: %w", err)
+	}
+	return parseMediaInfo(stdout.Bytes())
+}
+
+// Decode 实现 Backend 接口：解码为临时WAV文件后读取，
+// -ss 放在输入前以快速定位，解码时仍精确到样本
+func (b *FFmpegBackend) Decode(path string, options DecodeOptions) (*AudioData, error) {
+	if options.Start < 0 {
+		return nil, fmt.Errorf("start cannot be negative")
+	}
+
+	info, err := b.Probe(path)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := info.SelectAudioStream(options.LoadOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "goudub")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	wavPath := filepath.Join(tempDir, "temp.wav")
+
+	bitDepth := options.BitDepth
+	if bitDepth == 0 {
+		bitDepth = decodeBitDepth(*stream)
+	}
+
+	args := []string{"-v", "quiet", "-y"}
+	if options.Start > 0 {
+		args = append(args, "-ss", formatSeconds(options.Start))
+	}
+	args = append(args, "-i", path)
+	if options.Duration > 0 {
+		args = append(args, "-t", formatSeconds(options.Duration))
+	}
+	args = append(args, "-map", "0:"+strconv.Itoa(stream.Index))
+	if options.SampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(options.SampleRate))
+	}
+	if options.Channels > 0 {
+		args = append(args, "-ac", strconv.Itoa(options.Channels))
+	}
+	args = append(args,
+		"-acodec", pcmCodec(bitDepth),
+		"-f", "wav",
+		wavPath)
+	if err := b.Command(args...).Run(); err != nil {
+		return nil, fmt.Errorf("failed to convert audio to wav: %w", err)
+	}
+
+	audio, err := readWAVFile(wavPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decoded audio: %w", err)
+	}
+	if audio.ChannelMask == 0 {
+		audio.ChannelMask = channelLayoutMask(stream.ChannelLayout, audio.Channels)
+	}
+	// 多音轨时保留所选音轨的语言和标题
+	if len(info.AudioStreams()) > 1 {
+		audio.Tags = streamTags(info, *stream)
+	} else {
+		audio.Tags = collectTags(info)
+	}
+	return audio, nil
+}
+
+// DecodeStreams 实现 MultiStreamDecoder 接口：用一次 ffmpeg 调用将多个音频流分别解码
+func (b *FFmpegBackend) DecodeStreams(path string, streams []StreamInfo) ([]*AudioData, error) {
+	info, err := b.Probe(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "goudub")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	args := []string{"-v", "quiet", "-y", "-i", path}
+	wavPaths := make([]string, len(streams))
+	for i, stream := range streams {
+		wavPaths[i] = filepath.Join(tempDir, fmt.Sprintf("stream%d.wav", stream.Index))
+		args = append(args,
+			"-map", "0:"+strconv.Itoa(stream.Index),
+			"-acodec", pcmCodec(decodeBitDepth(stream)),
+			"-f", "wav",
+			wavPaths[i])
+	}
+	if err := b.Command(args...).Run(); err != nil {
+		return nil, fmt.Errorf("failed to decode audio streams: %w", err)
+	}
+
+	audios := make([]*AudioData, len(streams))
+	for i, stream := range streams {
+		audio, err := readWAVFile(wavPaths[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stream %d: %w", stream.Index, err)
+		}
+		if audio.ChannelMask == 0 {
+			audio.ChannelMask = channelLayoutMask(stream.ChannelLayout, audio.Channels)
+		}
+		audio.Tags = streamTags(info, stream)
+		audios[i] = audio
+	}
+	return audios, nil
+}
+
+// Encode 实现 Backend 接口：先写临时WAV文件，再由 ffmpeg 转换为目标格式
+func (b *FFmpegBackend) Encode(audio *AudioData, path string, format string, options ExportOptions) error {
+	if options.CoverArt != "" && !coverArtFormats[format] {
+		return fmt.Errorf("cover art is not supported for %s", format)
+	}
+
+	tempDir, err := os.MkdirTemp("", "goudub")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// 标签通过 -metadata 写入目标文件，临时文件不需要
+	wavPath := filepath.Join(tempDir, "temp.wav")
+	if err := writeWAVFile(wavPath, audio, nil, options); err != nil {
+		return err
+	}
+
+	// 根据格式选择适当的编码器和参数
+	args := []string{"-y", "-f", "wav", "-i", wavPath}
+	if options.CoverArt != "" {
+		args = append(args, "-i", options.CoverArt)
+		args = append(args, coverArtArgs(format)...)
+	}
+	switch format {
+	case "mp3":
+		args = append(args,
+			"-c:a", "libmp3lame",
+			"-b:a", "320k", // 使用高比特率
+		)
+	case "ogg":
+		args = append(args,
+			"-c:a", "libvorbis",
+			"-q:a", "10", // 使用高质量设置
+		)
+	case "flac":
+		args = append(args, "-c:a", "flac")
+	case "m4a":
+		args = append(args,
+			"-c:a", "aac",
+			"-b:a", "256k",
+			"-f", "ipod",
+		)
+	default:
+		args = append(args,
+			"-acodec", pcmCodec(audio.BitDepth),
+			"-f", format,
+		)
+	}
+	args = append(args,
+		"-ar", strconv.Itoa(audio.SampleRate),
+		"-ac", strconv.Itoa(audio.Channels),
+	)
+	args = append(args, metadataArgs(mergeTags(audio.Tags, options.Tags))...)
+
+	if err := b.Command(append(args, path)...).Run(); err != nil {
+		return fmt.Errorf("failed to convert wav to %s: %w", format, err)
+	}
+	return nil
+}
+
+// pcmCodec 返回位深度对应的 ffmpeg PCM 编码器名称（8 位 PCM 为无符号）
+func pcmCodec(bitDepth int) string {
+	if bitDepth == 8 {
+		return "pcm_u8"
+	}
+	return fmt.Sprintf("pcm_s%dle", bitDepth)
+}
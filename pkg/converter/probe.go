@@ -0,0 +1,245 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaInfo 媒体文件信息（容器、流和章节），由 ffprobe 读取，不解码音频
+type MediaInfo struct {
+	Format         string            // 容器格式（ffprobe format_name，可能为逗号分隔的多个名称）
+	FormatLongName string            // 容器格式全称
+	Duration       time.Duration     // 总时长
+	StartTime      time.Duration     // 起始时间
+	BitRate        int64             // 总比特率（bit/s）
+	Size           int64             // 文件大小（字节）
+	Tags           map[string]string // 容器级标签
+	Streams        []StreamInfo      // 所有流（音频、视频、字幕、附件等）
+	Chapters       []Chapter         // 章节
+}
+
+// StreamInfo 单个流的信息
+type StreamInfo struct {
+	Index         int    // 流在容器中的索引
+	CodecType     string // audio / video / subtitle / data / attachment
+	CodecName     string
+	CodecLongName string
+	Profile       string
+	SampleFormat  string // 例如 s16、fltp
+	SampleRate    int
+	Channels      int
+	ChannelLayout string
+	BitsPerSample int // 解码后的有效位数（bits_per_raw_sample 或 bits_per_sample），未知时为 0
+	BitRate       int64
+	Duration      time.Duration
+	StartTime     time.Duration
+	Width         int // 视频宽度
+	Height        int // 视频高度
+	Language      string
+	Title         string
+	Disposition   Disposition
+	Tags          map[string]string
+}
+
+// Disposition 流的用途标记
+type Disposition struct {
+	Default         bool
+	Dub             bool
+	Original        bool
+	Comment         bool
+	Lyrics          bool
+	Karaoke         bool
+	Forced          bool
+	HearingImpaired bool
+	VisualImpaired  bool
+	CleanEffects    bool
+	AttachedPic     bool // 封面图片
+}
+
+// Chapter 章节
+type Chapter struct {
+	ID    int64
+	Start time.Duration
+	End   time.Duration
+	Title string
+	Tags  map[string]string
+}
+
+// AudioStreams 返回所有音频流
+func (m *MediaInfo) AudioStreams() []StreamInfo {
+	var streams []StreamInfo
+	for _, stream := range m.Streams {
+		if stream.CodecType == "audio" {
+			streams = append(streams, stream)
+		}
+	}
+	return streams
+}
+
+// Tag 返回流的标签值，不区分大小写（不同容器的标签名大小写不一）
+func (s StreamInfo) Tag(name string) string {
+	return lookupTag(s.Tags, name)
+}
+
+// Tag 返回容器级标签值，不区分大小写
+func (m *MediaInfo) Tag(name string) string {
+	return lookupTag(m.Tags, name)
+}
+
+// ffprobe -show_format -show_streams -show_chapters 的 JSON 结构
+type probeOutput struct {
+	Format struct {
+		FormatName     string            `json:"format_name"`
+		FormatLongName string            `json:"format_long_name"`
+		Duration       string            `json:"duration"`
+		StartTime      string            `json:"start_time"`
+		BitRate        string            `json:"bit_rate"`
+		Size           string            `json:"size"`
+		Tags           map[string]string `json:"tags"`
+	} `json:"format"`
+	Streams []struct {
+		Index            int               `json:"index"`
+		CodecType        string            `json:"codec_type"`
+		CodecName        string            `json:"codec_name"`
+		CodecLongName    string            `json:"codec_long_name"`
+		Profile          string            `json:"profile"`
+		SampleFmt        string            `json:"sample_fmt"`
+		SampleRate       string            `json:"sample_rate"`
+		Channels         int               `json:"channels"`
+		ChannelLayout    string            `json:"channel_layout"`
+		BitsPerSample    int               `json:"bits_per_sample"`
+		BitsPerRawSample string            `json:"bits_per_raw_sample"`
+		BitRate          string            `json:"bit_rate"`
+		Duration         string            `json:"duration"`
+		StartTime        string            `json:"start_time"`
+		Width            int               `json:"width"`
+		Height           int               `json:"height"`
+		Disposition      map[string]int    `json:"disposition"`
+		Tags             map[string]string `json:"tags"`
+	} `json:"streams"`
+	Chapters []struct {
+		ID        int64             `json:"id"`
+		StartTime string            `json:"start_time"`
+		EndTime   string            `json:"end_time"`
+		Tags      map[string]string `json:"tags"`
+	} `json:"chapters"`
+}
+
+// probeArgs ffprobe 的公共参数
+var probeArgs = []string{"-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", "-show_chapters"}
+
+// Probe 读取媒体文件的容器、流和章节信息，使用扩展名对应的后端（未注册的格式使用 ffprobe）
+func Probe(path string) (*MediaInfo, error) {
+	return probe(path, formatOf(path))
+}
+
+// ProbeReader 从数据流读取媒体信息（通过标准输入传给 ffprobe），
+// 对于需要随机访问的容器（如 moov 在末尾的 mp4），部分信息可能缺失
+func ProbeReader(r io.Reader) (*MediaInfo, error) {
+	return FFmpeg.ProbeReader(r)
+}
+
+// parseMediaInfo 解析 ffprobe 的 JSON 输出
+func parseMediaInfo(data []byte) (*MediaInfo, error) {
+	var output probeOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse probe output: %w", err)
+	}
+
+	info := &MediaInfo{
+		Format:         output.Format.FormatName,
+		FormatLongName: output.Format.FormatLongName,
+		Duration:       parseSeconds(output.Format.Duration),
+		StartTime:      parseSeconds(output.Format.StartTime),
+		BitRate:        parseInt(output.Format.BitRate),
+		Size:           parseInt(output.Format.Size),
+		Tags:           output.Format.Tags,
+	}
+
+	for _, s := range output.Streams {
+		stream := StreamInfo{
+			Index:         s.Index,
+			CodecType:     s.CodecType,
+			CodecName:     s.CodecName,
+			CodecLongName: s.CodecLongName,
+			Profile:       s.Profile,
+			SampleFormat:  s.SampleFmt,
+			SampleRate:    int(parseInt(s.SampleRate)),
+			Channels:      s.Channels,
+			ChannelLayout: s.ChannelLayout,
+			BitsPerSample: int(parseInt(s.BitsPerRawSample)),
+			BitRate:       parseInt(s.BitRate),
+			Duration:      parseSeconds(s.Duration),
+			StartTime:     parseSeconds(s.StartTime),
+			Width:         s.Width,
+			Height:        s.Height,
+			Language:      lookupTag(s.Tags, "language"),
+			Title:         lookupTag(s.Tags, "title"),
+			Tags:          s.Tags,
+			Disposition: Disposition{
+				Default:         s.Disposition["default"] != 0,
+				Dub:             s.Disposition["dub"] != 0,
+				Original:        s.Disposition["original"] != 0,
+				Comment:         s.Disposition["comment"] != 0,
+				Lyrics:          s.Disposition["lyrics"] != 0,
+				Karaoke:         s.Disposition["karaoke"] != 0,
+				Forced:          s.Disposition["forced"] != 0,
+				HearingImpaired: s.Disposition["hearing_impaired"] != 0,
+				VisualImpaired:  s.Disposition["visual_impaired"] != 0,
+				CleanEffects:    s.Disposition["clean_effects"] != 0,
+				AttachedPic:     s.Disposition["attached_pic"] != 0,
+			},
+		}
+		if stream.BitsPerSample == 0 {
+			stream.BitsPerSample = s.BitsPerSample
+		}
+		info.Streams = append(info.Streams, stream)
+	}
+
+	for _, c := range output.Chapters {
+		info.Chapters = append(info.Chapters, Chapter{
+			ID:    c.ID,
+			Start: parseSeconds(c.StartTime),
+			End:   parseSeconds(c.EndTime),
+			Title: lookupTag(c.Tags, "title"),
+			Tags:  c.Tags,
+		})
+	}
+
+	return info, nil
+}
+
+// parseSeconds 解析 ffprobe 以秒为单位的字符串，无法解析时返回 0
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// parseInt 解析 ffprobe 的整数字符串，无法解析时返回 0
+func parseInt(value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// lookupTag 不区分大小写地查找标签
+func lookupTag(tags map[string]string, name string) string {
+	if value, ok := tags[name]; ok {
+		return value
+	}
+	for key, value := range tags {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
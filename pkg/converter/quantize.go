@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"math"
+	"math/rand"
+)
+
+// DitherType 抖动类型
+type DitherType int
+
+const (
+	// DitherNone 不加抖动，仅四舍五入
+	DitherNone DitherType = iota
+	// DitherRectangular 矩形分布抖动（±0.5 LSB）
+	DitherRectangular
+	// DitherTriangular 三角分布抖动（TPDF，±1 LSB）
+	DitherTriangular
+)
+
+// NoiseShaping 噪声整形滤波器类型
+type NoiseShaping int
+
+const (
+	// NoiseShapingNone 不做噪声整形
+	NoiseShapingNone NoiseShaping = iota
+	// NoiseShapingFirstOrder 一阶误差反馈，将量化噪声推向高频
+	NoiseShapingFirstOrder
+	// NoiseShapingLipshitz Lipshitz 五阶 E 加权噪声整形
+	NoiseShapingLipshitz
+)
+
+// 各噪声整形方式的误差反馈系数，噪声传递函数为 1 - Σ c[k]·z^-(k+1)
+var noiseShapingCoefficients = map[NoiseShaping][]float64{
+	NoiseShapingNone:       nil,
+	NoiseShapingFirstOrder: {1.0},
+	NoiseShapingLipshitz:   {2.033, -2.165, 1.959, -1.590, 0.6149},
+}
+
+// Quantizer 将 [-1, 1] 范围的浮点样本量化为整数，支持限幅、抖动和噪声整形
+type Quantizer struct {
+	bitDepth int
+	channels int
+	scale    float64
+	min, max float64
+
+	dither       DitherType
+	coefficients []float64
+	rng          *rand.Rand
+
+	// 每个声道最近的量化误差（LSB 为单位），errors[ch][0] 为最新
+	errors  [][]float64
+	channel int
+}
+
+// NewQuantizer 创建量化器，样本按声道交错依次传入
+func NewQuantizer(bitDepth, channels int, dither DitherType, shaping NoiseShaping) *Quantizer {
+	if channels <= 0 {
+		channels = 1
+	}
+	coefficients := noiseShapingCoefficients[shaping]
+
+	errs := make([][]float64, channels)
+	for ch := range errs {
+		errs[ch] = make([]float64, len(coefficients))
+	}
+
+	// 32 位无需抖动，量化误差远小于任何模拟噪声
+	if bitDepth >= 32 {
+		dither = DitherNone
+		coefficients = nil
+	}
+
+	maxValue := math.Ldexp(1, bitDepth-1) - 1
+	return &Quantizer{
+		bitDepth:     bitDepth,
+		channels:     channels,
+		scale:        maxValue,
+		min:          -maxValue - 1,
+		max:          maxValue,
+		dither:       dither,
+		coefficients: coefficients,
+		rng:          rand.New(rand.NewSource(1)),
+		errors:       errs,
+	}
+}
+
+// Quantize 量化一个样本，返回有符号整数值
+func (q *Quantizer) Quantize(sample float64) int32 {
+	errs := q.errors[q.channel]
+	q.channel = (q.channel + 1) % q.channels
+
+	value := sample * q.scale
+	for k, c := range q.coefficients {
+		value -= c * errs[k]
+	}
+
+	dithered := value
+	switch q.dither {
+	case DitherRectangular:
+		dithered += q.rng.Float64() - 0.5
+	case DitherTriangular:
+		dithered += q.rng.Float64() - q.rng.Float64()
+	}
+	rounded := math.Round(dithered)
+
+	if len(errs) > 0 {
+		copy(errs[1:], errs[:len(errs)-1])
+		errs[0] = rounded - value
+	}
+
+	// 限幅，避免溢出回绕
+	if rounded > q.max {
+		rounded = q.max
+	} else if rounded < q.min {
+		rounded = q.min
+	}
+	return int32(rounded)
+}
+
+// PutSample 将样本量化后按小端序写入 b，b 的长度至少为 bitDepth/8
+func (q *Quantizer) PutSample(b []byte, sample float64) {
+	value := q.Quantize(sample)
+	switch q.bitDepth {
+	case 8:
+		// 8 位 WAV 为无符号格式
+		b[0] = byte(value + 128)
+	case 16:
+		b[0] = byte(value)
+		b[1] = byte(value >> 8)
+	case 24:
+		b[0] = byte(value)
+		b[1] = byte(value >> 8)
+		b[2] = byte(value >> 16)
+	case 32:
+		b[0] = byte(value)
+		b[1] = byte(value >> 8)
+		b[2] = byte(value >> 16)
+		b[3] = byte(value >> 24)
+	}
+}
@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// LoadAudioFileRange 只加载从 start 开始、时长为 duration 的片段，duration 不大于 0 时加载到文件末尾。
+// PCM/浮点 WAV 直接按字节偏移读取，其他格式使用 ffmpeg 的 -ss/-t 精确定位后只解码所需部分
+func LoadAudioFileRange(path string, format string, start, duration time.Duration) (*AudioData, error) {
+	if start < 0 {
+		return nil, fmt.Errorf("start cannot be negative")
+	}
+	return decode(path, format, DecodeOptions{Start: start, Duration: duration})
+}
+
+// readWAVFrames 按时间范围读取数据块中的采样帧
+func readWAVFrames(r io.ReadSeeker, info *wavInfo, start, duration time.Duration) (*AudioData, error) {
+	frameSize := info.frameSize()
+	totalFrames := info.DataSize / frameSize
+	startFrame := int64(start.Seconds() * float64(info.SampleRate))
+	if startFrame > 0 && startFrame >= totalFrames {
+		return nil, fmt.Errorf("start %v is beyond the end of the file", start)
+	}
+
+	frames := totalFrames - startFrame
+	if duration > 0 {
+		if n := int64(duration.Seconds() * float64(info.SampleRate)); n < frames {
+			frames = n
+		}
+	}
+
+	if _, err := r.Seek(info.DataOffset+startFrame*frameSize, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek wav data: %w", err)
+	}
+	data := make([]byte, frames*frameSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("failed to read wav data: %w", err)
+	}
+
+	bitDepth := info.BitDepth
+	if bitDepth > 32 {
+		bitDepth = 32
+	}
+	return &AudioData{
+		Samples:     decodeWAVSamples(data, info.BitDepth, info.Float),
+		SampleRate:  info.SampleRate,
+		Channels:    info.Channels,
+		BitDepth:    bitDepth,
+		ChannelMask: info.ChannelMask,
+		Tags:        info.Tags,
+	}, nil
+}
+
+// formatSeconds 将时长格式化为 ffmpeg 接受的秒数
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 6, 64)
+}
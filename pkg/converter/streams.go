@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadOptions 从多音轨容器（mkv/mp4/mov 等）加载时选择音频流的条件，各条件同时满足
+type LoadOptions struct {
+	Index    int                   // 在满足其余条件的音频流中取第几个（从 0 开始，对应 ffmpeg 的 0:a:N）
+	Language string                // 语言标签，如 "eng"、"fre"，不区分大小写，为空时不限
+	Title    string                // 标题包含的文字，不区分大小写，为空时不限
+	Select   func(StreamInfo) bool // 自定义筛选条件（如按容器中的绝对索引或 disposition），为 nil 时不限
+}
+
+// SelectAudioStream 按加载选项选择音频流
+func (m *MediaInfo) SelectAudioStream(options LoadOptions) (*StreamInfo, error) {
+	if options.Index < 0 {
+		return nil, fmt.Errorf("stream index cannot be negative")
+	}
+
+	var matched []StreamInfo
+	for _, stream := range m.AudioStreams() {
+		if options.Language != "" && !strings.EqualFold(stream.Language, options.Language) {
+			continue
+		}
+		if options.Title != "" && !strings.Contains(strings.ToLower(stream.Title), strings.ToLower(options.Title)) {
+			continue
+		}
+		if options.Select != nil && !options.Select(stream) {
+			continue
+		}
+		matched = append(matched, stream)
+	}
+
+	if options.Index >= len(matched) {
+		return nil, fmt.Errorf("no audio stream matches the load options (%d candidates)", len(matched))
+	}
+	return &matched[options.Index], nil
+}
+
+// LoadAudioFileWithOptions 加载容器中按选项选中的音频流
+func LoadAudioFileWithOptions(path string, format string, options LoadOptions) (*AudioData, error) {
+	return decode(path, format, DecodeOptions{LoadOptions: options})
+}
+
+// LoadAllAudioStreams 解码容器中的所有音频流，按流顺序返回，同时返回对应的流信息；
+// 后端实现了 MultiStreamDecoder 时（如 ffmpeg）只需解码一次
+func LoadAllAudioStreams(path string) ([]*AudioData, []StreamInfo, error) {
+	format := formatOf(path)
+	info, err := probe(path, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	streams := info.AudioStreams()
+	if len(streams) == 0 {
+		return nil, nil, fmt.Errorf("no audio stream found")
+	}
+
+	if decoder, ok := BackendFor(format).(MultiStreamDecoder); ok {
+		audios, err := decoder.DecodeStreams(path, streams)
+		if err != nil {
+			return nil, nil, err
+		}
+		return audios, streams, nil
+	}
+
+	audios := make([]*AudioData, len(streams))
+	for i := range streams {
+		audio, err := decode(path, format, DecodeOptions{LoadOptions: LoadOptions{Index: i}})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode stream %d: %w", streams[i].Index, err)
+		}
+		audios[i] = audio
+	}
+	return audios, streams, nil
+}
+
+// readWAVFile 读取整个WAV文件
+func readWAVFile(path string) (*AudioData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wav file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := readWAVInfo(f)
+	if err != nil {
+		return nil, err
+	}
+	return readWAVFrames(f, info, 0, 0)
+}
+
+// decodeBitDepth 返回解码音频流时使用的位深度（保留 24/32 位源的精度，其余使用 16 位）
+func decodeBitDepth(stream StreamInfo) int {
+	if stream.BitsPerSample == 24 || stream.BitsPerSample == 32 {
+		return stream.BitsPerSample
+	}
+	return 16
+}
+
+// streamTags 合并容器级标签和指定流的标签（流的标签优先，保留语言和标题以区分音轨）
+func streamTags(info *MediaInfo, stream StreamInfo) map[string]string {
+	tags := make(map[string]string)
+	for key, value := range info.Tags {
+		tags[strings.ToLower(key)] = value
+	}
+	for key, value := range stream.Tags {
+		tags[strings.ToLower(key)] = value
+	}
+	for key := range tags {
+		if technicalTags[key] && key != "language" {
+			delete(tags, key)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
@@ -0,0 +1,167 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 通用标签名对应的 RIFF INFO 子块 ID，未列出的标签不会写入 WAV
+var riffInfoIDs = map[string]string{
+	"title":     "INAM",
+	"artist":    "IART",
+	"album":     "IPRD",
+	"comment":   "ICMT",
+	"date":      "ICRD",
+	"genre":     "IGNR",
+	"copyright": "ICOP",
+	"track":     "ITRK",
+	"encoder":   "ISFT",
+	"engineer":  "IENG",
+	"language":  "ILNG",
+}
+
+// 加载时忽略的技术性标签（由封装器生成，不是用户元数据）
+var technicalTags = map[string]bool{
+	"handler_name":      true,
+	"vendor_id":         true,
+	"major_brand":       true,
+	"minor_version":     true,
+	"compatible_brands": true,
+	"encoder":           true,
+	"language":          true,
+}
+
+// 支持写入封面图片的格式
+var coverArtFormats = map[string]bool{
+	"mp3": true,
+	"m4a": true,
+}
+
+// mergeTags 合并标签（标签名统一为小写），override 中值为空字符串的标签会被删除
+func mergeTags(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		tags[strings.ToLower(key)] = value
+	}
+	for key, value := range override {
+		key = strings.ToLower(key)
+		if value == "" {
+			delete(tags, key)
+			continue
+		}
+		tags[key] = value
+	}
+	return tags
+}
+
+// collectTags 从媒体信息中收集用户标签：先取第一个音频流的标签，再用容器级标签覆盖
+func collectTags(info *MediaInfo) map[string]string {
+	tags := make(map[string]string)
+	if streams := info.AudioStreams(); len(streams) > 0 {
+		for key, value := range streams[0].Tags {
+			tags[strings.ToLower(key)] = value
+		}
+	}
+	for key, value := range info.Tags {
+		tags[strings.ToLower(key)] = value
+	}
+	for key := range tags {
+		if technicalTags[key] {
+			delete(tags, key)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// sortedKeys 返回按字母排序的标签名，保证输出稳定
+func sortedKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// metadataArgs 生成 ffmpeg 的 -metadata 参数
+func metadataArgs(tags map[string]string) []string {
+	var args []string
+	for _, key := range sortedKeys(tags) {
+		args = append(args, "-metadata", key+"="+tags[key])
+	}
+	return args
+}
+
+// buildInfoChunk 生成 RIFF LIST/INFO 块，没有可写入的标签时返回 nil
+func buildInfoChunk(tags map[string]string) []byte {
+	var body bytes.Buffer
+	for _, key := range sortedKeys(tags) {
+		id, ok := riffInfoIDs[key]
+		if !ok {
+			continue
+		}
+		// 值以 NUL 结尾，子块按偶数字节对齐
+		value := append([]byte(tags[key]), 0)
+		body.WriteString(id)
+		binary.Write(&body, binary.LittleEndian, uint32(len(value)))
+		body.Write(value)
+		if len(value)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+	if body.Len() == 0 {
+		return nil
+	}
+
+	var chunk bytes.Buffer
+	chunk.WriteString("LIST")
+	binary.Write(&chunk, binary.LittleEndian, uint32(4+body.Len()))
+	chunk.WriteString("INFO")
+	chunk.Write(body.Bytes())
+	return chunk.Bytes()
+}
+
+// coverArtArgs 生成将封面图片作为附加图片写入的 ffmpeg 参数（封面为第二个输入）
+func coverArtArgs(format string) []string {
+	args := []string{"-map", "0:a", "-map", "1:v", "-c:v", "copy", "-disposition:v:0", "attached_pic"}
+	if format == "mp3" {
+		args = append(args, "-id3v2_version", "3", "-metadata:s:v", "title=Album cover", "-metadata:s:v", "comment=Cover (front)")
+	}
+	return args
+}
+
+// ExtractCoverArt 将媒体文件中的封面图片（attached_pic 流）原样写入 w
+func ExtractCoverArt(path string, w io.Writer) error {
+	info, err := Probe(path)
+	if err != nil {
+		return err
+	}
+
+	for _, stream := range info.Streams {
+		if !stream.Disposition.AttachedPic {
+			continue
+		}
+		cmd := FFmpeg.Command("-v", "quiet", "-i", path,
+			"-map", "0:"+strconv.Itoa(stream.Index),
+			"-c", "copy",
+			"-f", "image2pipe",
+			"pipe:1")
+		cmd.Stdout = w
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to extract cover art: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("no cover art found in %s", path)
+}
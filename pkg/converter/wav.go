@@ -1,8 +1,16 @@
 package converter
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
+	"math"
+	"math/bits"
+	"os"
+	"strings"
+	"time"
 )
 
 // WAV文件头结构
@@ -22,8 +30,94 @@ type wavHeader struct {
 	Subchunk2Size uint32  // 数据大小
 }
 
-// writeWAVHeader 写入WAV文件头
-func writeWAVHeader(w io.Writer, sampleRate, channels, bitDepth int, dataSize int) error {
+// WAV扩展格式文件头结构（WAVE_FORMAT_EXTENSIBLE）
+type wavExtensibleHeader struct {
+	ChunkID            [4]byte  // "RIFF"
+	ChunkSize          uint32   // 文件大小 - 8
+	Format             [4]byte  // "WAVE"
+	Subchunk1ID        [4]byte  // "fmt "
+	Subchunk1Size      uint32   // 40
+	AudioFormat        uint16   // 0xFFFE
+	NumChannels        uint16   // 声道数
+	SampleRate         uint32   // 采样率
+	ByteRate           uint32   // SampleRate * NumChannels * BitsPerSample/8
+	BlockAlign         uint16   // NumChannels * BitsPerSample/8
+	BitsPerSample      uint16   // 8, 16, 24, 32
+	ExtensionSize      uint16   // 22
+	ValidBitsPerSample uint16   // 有效位数
+	ChannelMask        uint32   // 声道掩码
+	SubFormat          [16]byte // KSDATAFORMAT_SUBTYPE_PCM
+	Subchunk2ID        [4]byte  // "data"
+	Subchunk2Size      uint32   // 数据大小
+}
+
+// PCM 子格式 GUID：00000001-0000-0010-8000-00aa00389b71
+var pcmSubFormat = [16]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}
+
+// 常用声道数对应的默认声道掩码
+var defaultChannelMasks = map[int]uint32{
+	1: 0x4,
+	2: 0x3,
+	3: 0xb,
+	4: 0x33,
+	6: 0x3f,
+	8: 0x63f,
+}
+
+// ffmpeg 声道布局名称对应的声道掩码
+var ffmpegChannelLayouts = map[string]uint32{
+	"mono":      0x4,
+	"stereo":    0x3,
+	"2.1":       0xb,
+	"3.0":       0x7,
+	"quad":      0x33,
+	"5.0":       0x37,
+	"5.1":       0x3f,
+	"5.0(side)": 0x607,
+	"5.1(side)": 0x60f,
+	"7.1":       0x63f,
+}
+
+// channelLayoutMask 将 ffprobe 报告的声道布局名称转换为声道掩码，无法识别时返回 0
+func channelLayoutMask(layout string, channels int) uint32 {
+	mask, ok := ffmpegChannelLayouts[layout]
+	if !ok || bits.OnesCount32(mask) != channels {
+		return 0
+	}
+	return mask
+}
+
+// writeWAVHeader 写入WAV文件头，多声道或非默认声道布局时使用扩展格式，
+// trailerSize 为数据块之后附加块（如 LIST/INFO）的总字节数
+func writeWAVHeader(w io.Writer, sampleRate, channels, bitDepth int, dataSize int, channelMask uint32, trailerSize int) error {
+	if channels > 2 || (channelMask != 0 && channelMask != defaultChannelMasks[channels]) {
+		if channelMask == 0 {
+			channelMask = defaultChannelMasks[channels]
+		}
+		header := wavExtensibleHeader{
+			ChunkID:            [4]byte{'R', 'I', 'F', 'F'},
+			Format:             [4]byte{'W', 'A', 'V', 'E'},
+			Subchunk1ID:        [4]byte{'f', 'm', 't', ' '},
+			Subchunk1Size:      40,
+			AudioFormat:        0xfffe,
+			NumChannels:        uint16(channels),
+			SampleRate:         uint32(sampleRate),
+			BitsPerSample:      uint16(bitDepth),
+			ExtensionSize:      22,
+			ValidBitsPerSample: uint16(bitDepth),
+			ChannelMask:        channelMask,
+			SubFormat:          pcmSubFormat,
+			Subchunk2ID:        [4]byte{'d', 'a', 't', 'a'},
+			Subchunk2Size:      uint32(dataSize),
+		}
+
+		header.ByteRate = uint32(sampleRate * channels * bitDepth / 8)
+		header.BlockAlign = uint16(channels * bitDepth / 8)
+		header.ChunkSize = 60 + header.Subchunk2Size + uint32(trailerSize)
+
+		return binary.Write(w, binary.LittleEndian, &header)
+	}
+
 	header := wavHeader{
 		ChunkID:       [4]byte{'R', 'I', 'F', 'F'},
 		Format:        [4]byte{'W', 'A', 'V', 'E'},
@@ -39,41 +133,360 @@ func writeWAVHeader(w io.Writer, sampleRate, channels, bitDepth int, dataSize in
 
 	header.ByteRate = uint32(sampleRate * channels * bitDepth / 8)
 	header.BlockAlign = uint16(channels * bitDepth / 8)
-	header.ChunkSize = 36 + header.Subchunk2Size
+	header.ChunkSize = 36 + header.Subchunk2Size + uint32(trailerSize)
 
 	return binary.Write(w, binary.LittleEndian, &header)
 }
 
-// writeWAVData 写入WAV数据
-func writeWAVData(w io.Writer, samples []float64, bitDepth int) error {
-	for _, sample := range samples {
-		switch bitDepth {
-		case 8:
-			value := uint8((sample + 1.0) * 127.5)
-			if err := binary.Write(w, binary.LittleEndian, value); err != nil {
-				return err
+// writeWAVData 写入WAV数据，样本经过限幅、抖动和噪声整形后量化
+func writeWAVData(w io.Writer, samples []float64, channels, bitDepth int, dither DitherType, shaping NoiseShaping) error {
+	quantizer := NewQuantizer(bitDepth, channels, dither, shaping)
+	bytesPerSample := bitDepth / 8
+
+	// 分块写入，避免逐样本调用 Write
+	const chunkSamples = 4096
+	buf := make([]byte, chunkSamples*bytesPerSample)
+	for start := 0; start < len(samples); start += chunkSamples {
+		end := start + chunkSamples
+		if end > len(samples) {
+			end = len(samples)
+		}
+		for i, sample := range samples[start:end] {
+			quantizer.PutSample(buf[i*bytesPerSample:], sample)
+		}
+		if _, err := w.Write(buf[:(end-start)*bytesPerSample]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wavInfo 解析WAV文件头得到的格式信息和数据块位置
+type wavInfo struct {
+	SampleRate  int
+	Channels    int
+	BitDepth    int
+	Float       bool   // IEEE 浮点样本
+	ChannelMask uint32 // 扩展格式中的声道掩码，普通PCM为 0
+	DataOffset  int64  // 数据块在文件中的起始位置
+	DataSize    int64  // 数据块字节数
+	Tags        map[string]string
+}
+
+// frameSize 返回每个采样帧的字节数
+func (w *wavInfo) frameSize() int64 {
+	return int64(w.Channels * w.BitDepth / 8)
+}
+
+// readWAVInfo 遍历 RIFF 块解析WAV格式、数据块位置和 LIST/INFO 标签，不读取样本数据
+func readWAVInfo(r io.ReadSeeker) (*wavInfo, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("failed to read riff header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a wav file")
+	}
+
+	info := &wavInfo{DataOffset: -1}
+	offset := int64(12)
+	var haveFormat bool
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			break
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		offset += 8
+
+		switch id {
+		case "fmt ":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("failed to read fmt chunk: %w", err)
 			}
-		case 16:
-			value := int16(sample * 32767.0)
-			if err := binary.Write(w, binary.LittleEndian, value); err != nil {
-				return err
+			if err := info.parseFormat(body); err != nil {
+				return nil, err
 			}
-		case 24:
-			value := int32(sample * 8388607.0)
-			bytes := []byte{
-				byte(value),
-				byte(value >> 8),
-				byte(value >> 16),
+			haveFormat = true
+		case "data":
+			info.DataOffset = offset
+			info.DataSize = size
+			// 流式写入的文件可能把数据大小记为 0 或最大值，以文件实际长度为准
+			if end, err := r.Seek(0, io.SeekEnd); err == nil && (size == 0 || offset+size > end) {
+				info.DataSize = end - offset
+				size = info.DataSize
 			}
-			if _, err := w.Write(bytes); err != nil {
-				return err
+		case "LIST":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("failed to read list chunk: %w", err)
 			}
-		case 32:
-			value := int32(sample * 2147483647.0)
-			if err := binary.Write(w, binary.LittleEndian, value); err != nil {
-				return err
+			if tags := parseInfoChunk(body); tags != nil {
+				info.Tags = tags
 			}
 		}
+
+		// 块按偶数字节对齐
+		offset += size + size%2
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	if !haveFormat {
+		return nil, fmt.Errorf("missing fmt chunk")
+	}
+	if info.DataOffset < 0 {
+		return nil, fmt.Errorf("missing data chunk")
+	}
+	return info, nil
+}
+
+// parseFormat 解析 fmt 块，支持整数PCM、IEEE浮点及其扩展格式
+func (w *wavInfo) parseFormat(body []byte) error {
+	if len(body) < 16 {
+		return fmt.Errorf("fmt chunk too short")
+	}
+	format := binary.LittleEndian.Uint16(body[0:2])
+	w.Channels = int(binary.LittleEndian.Uint16(body[2:4]))
+	w.SampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+	w.BitDepth = int(binary.LittleEndian.Uint16(body[14:16]))
+
+	if format == 0xfffe {
+		if len(body) < 40 {
+			return fmt.Errorf("extensible fmt chunk too short")
+		}
+		w.ChannelMask = binary.LittleEndian.Uint32(body[20:24])
+		// 子格式 GUID 的前两个字节为实际格式代码
+		format = binary.LittleEndian.Uint16(body[24:26])
+	}
+
+	switch {
+	case format == 1 && (w.BitDepth == 8 || w.BitDepth == 16 || w.BitDepth == 24 || w.BitDepth == 32):
+	case format == 3 && (w.BitDepth == 32 || w.BitDepth == 64):
+		w.Float = true
+	default:
+		return fmt.Errorf("unsupported wav format %#x with %d bits", format, w.BitDepth)
+	}
+	if w.Channels <= 0 || w.SampleRate <= 0 {
+		return fmt.Errorf("invalid wav format")
 	}
 	return nil
 }
+
+// parseInfoChunk 解析 LIST/INFO 块为通用标签，不是 INFO 列表时返回 nil
+func parseInfoChunk(body []byte) map[string]string {
+	if len(body) < 4 || string(body[0:4]) != "INFO" {
+		return nil
+	}
+	names := make(map[string]string, len(riffInfoIDs))
+	for name, id := range riffInfoIDs {
+		names[id] = name
+	}
+
+	tags := make(map[string]string)
+	for pos := 4; pos+8 <= len(body); {
+		id := string(body[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(body[pos+4 : pos+8]))
+		pos += 8
+		if pos+size > len(body) {
+			break
+		}
+		value := strings.TrimRight(string(bytes.TrimRight(body[pos:pos+size], "\x00")), " ")
+		if name, ok := names[id]; ok && value != "" {
+			tags[name] = value
+		}
+		pos += size + size%2
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// decodeWAVSamples 将PCM字节数据转换为 [-1, 1] 范围的浮点样本
+func decodeWAVSamples(data []byte, bitDepth int, float bool) []float64 {
+	bytesPerSample := bitDepth / 8
+	samples := make([]float64, len(data)/bytesPerSample)
+	for i := range samples {
+		b := data[i*bytesPerSample:]
+		switch {
+		case float && bitDepth == 32:
+			samples[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case float && bitDepth == 64:
+			samples[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case bitDepth == 8:
+			samples[i] = float64(int32(b[0])-128) / 128.0
+		case bitDepth == 16:
+			samples[i] = float64(int16(binary.LittleEndian.Uint16(b))) / 32768.0
+		case bitDepth == 24:
+			sample := int32(b[0]) | int32(b[1])<<8 | int32(b[2])<<16
+			if sample&0x800000 != 0 {
+				sample |= ^0xffffff
+			}
+			samples[i] = float64(sample) / 8388608.0
+		case bitDepth == 32:
+			samples[i] = float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648.0
+		}
+	}
+	return samples
+}
+
+// writeWAVFile 将音频数据写入WAV文件，标签以 LIST/INFO 块写在数据块之后
+func writeWAVFile(path string, audio *AudioData, tags map[string]string, options ExportOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create wav file: %w", err)
+	}
+	defer f.Close()
+
+	// 计算数据大小
+	dataSize := len(audio.Samples) * audio.BitDepth / 8
+
+	// 数据块为奇数字节时需补齐
+	trailer := buildInfoChunk(tags)
+	if trailer != nil && dataSize%2 == 1 {
+		trailer = append([]byte{0}, trailer...)
+	}
+
+	w := bufio.NewWriter(f)
+	if err := writeWAVHeader(w, audio.SampleRate, audio.Channels, audio.BitDepth, dataSize, audio.ChannelMask, len(trailer)); err != nil {
+		return fmt.Errorf("failed to write wav header: %w", err)
+	}
+	if err := writeWAVData(w, audio.Samples, audio.Channels, audio.BitDepth, options.Dither, options.NoiseShaping); err != nil {
+		return fmt.Errorf("failed to write wav data: %w", err)
+	}
+	if _, err := w.Write(trailer); err != nil {
+		return fmt.Errorf("failed to write wav tags: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write wav file: %w", err)
+	}
+
+	// 确保文件已完全写入
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync wav file: %w", err)
+	}
+	return nil
+}
+
+// WAVBackend 纯 Go 实现的WAV后端，支持整数PCM和IEEE浮点格式，不需要 ffmpeg
+type WAVBackend struct{}
+
+func init() {
+	RegisterBackend("wav", WAVBackend{})
+	RegisterBackend("wave", WAVBackend{})
+}
+
+// Name 实现 Backend 接口
+func (WAVBackend) Name() string {
+	return "wav"
+}
+
+// Probe 实现 Backend 接口
+func (WAVBackend) Probe(path string) (*MediaInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wav file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := readWAVInfo(f)
+	if err != nil {
+		return nil, unsupported("%v", err)
+	}
+	media := info.mediaInfo()
+	if stat, err := f.Stat(); err == nil {
+		media.Size = stat.Size()
+	}
+	return media, nil
+}
+
+// Decode 实现 Backend 接口，按字节偏移直接读取所需范围；
+// 压缩编码（如 ADPCM）或需要重采样、改变声道数时返回 ErrUnsupported
+func (WAVBackend) Decode(path string, options DecodeOptions) (*AudioData, error) {
+	if options.Start < 0 {
+		return nil, fmt.Errorf("start cannot be negative")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wav file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := readWAVInfo(f)
+	if err != nil {
+		return nil, unsupported("%v", err)
+	}
+	if options.SampleRate > 0 && options.SampleRate != info.SampleRate {
+		return nil, unsupported("resampling from %d Hz to %d Hz", info.SampleRate, options.SampleRate)
+	}
+	if options.Channels > 0 && options.Channels != info.Channels {
+		return nil, unsupported("converting %d channels to %d", info.Channels, options.Channels)
+	}
+	if _, err := info.mediaInfo().SelectAudioStream(options.LoadOptions); err != nil {
+		return nil, err
+	}
+
+	audio, err := readWAVFrames(f, info, options.Start, options.Duration)
+	if err != nil {
+		return nil, err
+	}
+	// 样本已是浮点，改变位深度只影响导出
+	if options.BitDepth > 0 {
+		audio.BitDepth = options.BitDepth
+	}
+	return audio, nil
+}
+
+// Encode 实现 Backend 接口
+func (WAVBackend) Encode(audio *AudioData, path string, format string, options ExportOptions) error {
+	if options.CoverArt != "" {
+		return fmt.Errorf("cover art is not supported for %s", format)
+	}
+	return writeWAVFile(path, audio, mergeTags(audio.Tags, options.Tags), options)
+}
+
+// mediaInfo 将WAV格式信息转换为只有一个音频流的媒体信息
+func (w *wavInfo) mediaInfo() *MediaInfo {
+	codec := pcmCodec(w.BitDepth)
+	if w.Float {
+		codec = fmt.Sprintf("pcm_f%dle", w.BitDepth)
+	}
+
+	mask := w.ChannelMask
+	if mask == 0 {
+		mask = defaultChannelMasks[w.Channels]
+	}
+	var layout string
+	for name, m := range ffmpegChannelLayouts {
+		if m == mask {
+			layout = name
+			break
+		}
+	}
+
+	byteRate := int64(w.SampleRate) * w.frameSize()
+	duration := time.Duration(float64(w.DataSize/w.frameSize()) / float64(w.SampleRate) * float64(time.Second))
+	return &MediaInfo{
+		Format:         "wav",
+		FormatLongName: "WAV / WAVE (Waveform Audio)",
+		Duration:       duration,
+		BitRate:        byteRate * 8,
+		Tags:           w.Tags,
+		Streams: []StreamInfo{{
+			CodecType:     "audio",
+			CodecName:     codec,
+			SampleRate:    w.SampleRate,
+			Channels:      w.Channels,
+			ChannelLayout: layout,
+			BitsPerSample: w.BitDepth,
+			BitRate:       byteRate * 8,
+			Duration:      duration,
+			Disposition:   Disposition{Default: true},
+		}},
+	}
+}
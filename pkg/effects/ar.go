@@ -0,0 +1,226 @@
+package effects
+
+import (
+	"math"
+	"sort"
+)
+
+// arCoefficients 用自相关法（Levinson-Durbin）估计AR模型系数，可传入多段互不相连的样本
+// 返回的 a[k]（k 从 1 开始，a[0] 未使用）满足 x[n] ≈ Σ a[k]·x[n-k]
+func arCoefficients(order int, segments ...[]float64) []float64 {
+	a := make([]float64, order+1)
+
+	r := make([]float64, order+1)
+	for _, samples := range segments {
+		for lag := 0; lag <= order && lag < len(samples); lag++ {
+			for i := lag; i < len(samples); i++ {
+				r[lag] += samples[i] * samples[i-lag]
+			}
+		}
+	}
+	if r[0] == 0 {
+		return a
+	}
+	// 轻微的对角加载，避免病态
+	r[0] *= 1 + 1e-9
+
+	prev := make([]float64, order+1)
+	e := r[0]
+	for i := 1; i <= order; i++ {
+		acc := r[i]
+		for j := 1; j < i; j++ {
+			acc -= a[j] * r[i-j]
+		}
+		k := acc / e
+
+		copy(prev, a)
+		a[i] = k
+		for j := 1; j < i; j++ {
+			a[j] = prev[j] - k*prev[i-j]
+		}
+		e *= 1 - k*k
+		if e <= 0 {
+			break
+		}
+	}
+	return a
+}
+
+// arResidual 计算AR模型的预测残差，前 order 个样本的残差记为0
+func arResidual(samples []float64, a []float64) []float64 {
+	order := len(a) - 1
+	residual := make([]float64, len(samples))
+	for n := order; n < len(samples); n++ {
+		pred := 0.0
+		for k := 1; k <= order; k++ {
+			pred += a[k] * samples[n-k]
+		}
+		residual[n] = samples[n] - pred
+	}
+	return residual
+}
+
+// lsarInterpolate 用最小二乘AR插值（LSAR）原地重建 missing 标记的样本，
+// 使整个窗口内的预测误差能量最小
+func lsarInterpolate(x []float64, missing []bool, a []float64) bool {
+	order := len(a) - 1
+
+	index := make(map[int]int)
+	var unknown []int
+	for i, m := range missing {
+		if m {
+			index[i] = len(unknown)
+			unknown = append(unknown, i)
+		}
+	}
+	if len(unknown) == 0 {
+		return true
+	}
+
+	count := len(unknown)
+	matrix := make([][]float64, count)
+	for i := range matrix {
+		matrix[i] = make([]float64, count)
+	}
+	rhs := make([]float64, count)
+
+	// 每一行预测误差 e[n] = x[n] - Σ a[k]·x[n-k]，拆分为未知部分和已知部分
+	coeff := make([]float64, order+1)
+	coeff[0] = 1
+	for k := 1; k <= order; k++ {
+		coeff[k] = -a[k]
+	}
+
+	first := unknown[0]
+	if first < order {
+		first = order
+	}
+	last := unknown[count-1] + order
+	if last > len(x)-1 {
+		last = len(x) - 1
+	}
+
+	rowUnknown := make([]float64, count)
+	for n := first; n <= last; n++ {
+		for i := range rowUnknown {
+			rowUnknown[i] = 0
+		}
+		known := 0.0
+		involved := false
+		for k := 0; k <= order; k++ {
+			j := n - k
+			if idx, ok := index[j]; ok {
+				rowUnknown[idx] = coeff[k]
+				involved = true
+			} else {
+				known += coeff[k] * x[j]
+			}
+		}
+		if !involved {
+			continue
+		}
+		for i := 0; i < count; i++ {
+			if rowUnknown[i] == 0 {
+				continue
+			}
+			rhs[i] -= rowUnknown[i] * known
+			for j := 0; j < count; j++ {
+				matrix[i][j] += rowUnknown[i] * rowUnknown[j]
+			}
+		}
+	}
+
+	solution := solveLinear(matrix, rhs)
+	if solution == nil {
+		return false
+	}
+	for i, pos := range unknown {
+		x[pos] = solution[i]
+	}
+	return true
+}
+
+// interpolateGap 使用前后上下文估计AR模型，通过LSAR插值原地重建 [start, start+length) 区间
+func interpolateGap(samples []float64, start, length, order int) bool {
+	context := 8 * order
+	if context < 2*length {
+		context = 2 * length
+	}
+
+	from := start - context
+	if from < 0 {
+		from = 0
+	}
+	to := start + length + context
+	if to > len(samples) {
+		to = len(samples)
+	}
+	window := samples[from:to]
+
+	// 仅用完好的上下文样本估计AR系数
+	if healthy := len(window) - length; order >= healthy/2 {
+		order = healthy / 2
+	}
+	if order < 1 {
+		return false
+	}
+	a := arCoefficients(order, samples[from:start], samples[start+length:to])
+
+	missing := make([]bool, len(window))
+	for i := start - from; i < start-from+length; i++ {
+		missing[i] = true
+	}
+	return lsarInterpolate(window, missing, a)
+}
+
+// solveLinear 使用列主元高斯消元求解线性方程组，矩阵奇异时返回 nil
+func solveLinear(matrix [][]float64, rhs []float64) []float64 {
+	n := len(rhs)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(matrix[row][col]) > math.Abs(matrix[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(matrix[pivot][col]) < 1e-12 {
+			return nil
+		}
+		matrix[col], matrix[pivot] = matrix[pivot], matrix[col]
+		rhs[col], rhs[pivot] = rhs[pivot], rhs[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := matrix[row][col] / matrix[col][col]
+			if factor == 0 {
+				continue
+			}
+			for k := col; k < n; k++ {
+				matrix[row][k] -= factor * matrix[col][k]
+			}
+			rhs[row] -= factor * rhs[col]
+		}
+	}
+
+	solution := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := rhs[row]
+		for k := row + 1; k < n; k++ {
+			sum -= matrix[row][k] * solution[k]
+		}
+		solution[row] = sum / matrix[row][row]
+	}
+	return solution
+}
+
+// median 返回切片的中位数（会打乱输入顺序）
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
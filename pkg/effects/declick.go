@@ -0,0 +1,137 @@
+package effects
+
+import (
+	"math"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+const (
+	// 咔嗒声检测所用AR模型的阶数和分块大小
+	clickAROrder   = 24
+	clickBlockSize = 4096
+	// 单个咔嗒声的最长持续时间，更长的突变视为正常瞬态
+	clickMaxDuration = 3 * time.Millisecond
+	// 检测区域两侧额外扩展的样本数
+	clickMargin = 2
+)
+
+// Click 表示在某个声道上检测到的一次咔嗒声
+type Click struct {
+	Channel  int           // 声道索引
+	Start    int           // 起始样本（按声道计）
+	Length   int           // 受损样本数
+	Position time.Duration // 起始时间
+	Severity float64       // 严重程度（AR残差峰值与鲁棒标准差之比）
+}
+
+// DetectClicks 基于AR预测残差检测每个声道上的咔嗒声
+// threshold 为残差超过鲁棒标准差的倍数，越小越敏感（典型值 4~10）
+func DetectClicks(segment *audio.AudioSegment, threshold float64) ([]Click, error) {
+	if threshold <= 0 {
+		return nil, errors.New("threshold must be positive")
+	}
+
+	sampleRate := segment.SampleRate()
+	var clicks []Click
+	for ch, samples := range segment.SplitChannels() {
+		clicks = append(clicks, detectChannelClicks(samples, ch, sampleRate, threshold)...)
+	}
+	return clicks, nil
+}
+
+// Declick 检测咔嗒声并用AR插值修复受损样本
+func Declick(segment *audio.AudioSegment, threshold float64) (*audio.AudioSegment, error) {
+	clicks, err := DetectClicks(segment, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	data := segment.SplitChannels()
+	for _, click := range clicks {
+		interpolateGap(data[click.Channel], click.Start, click.Length, clickAROrder)
+	}
+
+	result, err := audio.NewAudioSegmentFromChannels(data, segment.SampleRate(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// detectChannelClicks 在单个声道上分块拟合AR模型并查找残差异常区域
+func detectChannelClicks(samples []float64, channel, sampleRate int, threshold float64) []Click {
+	maxLength := int(clickMaxDuration.Seconds() * float64(sampleRate))
+	if maxLength < 1 {
+		maxLength = 1
+	}
+
+	var clicks []Click
+	for blockStart := 0; blockStart < len(samples); blockStart += clickBlockSize {
+		// 多取 order 个历史样本，使块首也能计算残差
+		from := blockStart - clickAROrder
+		if from < 0 {
+			from = 0
+		}
+		to := blockStart + clickBlockSize
+		if to > len(samples) {
+			to = len(samples)
+		}
+		block := samples[from:to]
+
+		a := arCoefficients(clickAROrder, block)
+		residual := arResidual(block, a)
+
+		magnitudes := make([]float64, 0, len(residual))
+		for _, e := range residual[clickAROrder:] {
+			magnitudes = append(magnitudes, math.Abs(e))
+		}
+		sigma := 1.4826 * median(magnitudes)
+		if sigma == 0 {
+			continue
+		}
+		limit := threshold * sigma
+
+		offset := blockStart - from
+		for i := offset; i < len(residual); i++ {
+			if math.Abs(residual[i]) <= limit {
+				continue
+			}
+
+			// 合并间隔很小的超阈值样本，视为同一个咔嗒声
+			end := i
+			peak := math.Abs(residual[i])
+			for j := i + 1; j < len(residual) && j <= end+2*clickMargin; j++ {
+				if v := math.Abs(residual[j]); v > limit {
+					end = j
+					if v > peak {
+						peak = v
+					}
+				}
+			}
+
+			start := from + i - clickMargin
+			if start < 0 {
+				start = 0
+			}
+			stop := from + end + clickMargin
+			if stop >= len(samples) {
+				stop = len(samples) - 1
+			}
+			if length := stop - start + 1; length <= maxLength {
+				clicks = append(clicks, Click{
+					Channel:  channel,
+					Start:    start,
+					Length:   length,
+					Position: time.Duration(float64(start) / float64(sampleRate) * float64(time.Second)),
+					Severity: peak / sigma,
+				})
+			}
+			// 咔嗒声会通过AR系数在随后 order 个样本的残差中产生回波，跳过这段区域
+			i = end + clickAROrder
+		}
+	}
+	return clicks
+}
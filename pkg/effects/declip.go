@@ -0,0 +1,161 @@
+package effects
+
+import (
+	"math"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+const (
+	// 削波修复所用AR模型的阶数
+	clipAROrder = 32
+	// AR插值的最长削波区段，更长的区段（如重度限幅的母带）求解代价过高，改用三次插值
+	clipMaxDuration = 5 * time.Millisecond
+)
+
+// ClippedRun 表示某个声道上一段连续的削波样本
+type ClippedRun struct {
+	Channel int           // 声道索引
+	Start   int           // 起始样本（按声道计）
+	Length  int           // 连续削波的样本数
+	From    time.Duration // 起始时间
+	To      time.Duration // 结束时间
+}
+
+// ClippingReport 削波分析结果
+type ClippingReport struct {
+	Runs           []ClippedRun // 所有削波区段
+	ClippedSamples []int        // 每个声道的削波样本数
+	RunCounts      []int        // 每个声道的削波区段数
+}
+
+// Clipped 判断是否存在削波
+func (r *ClippingReport) Clipped() bool {
+	return len(r.Runs) > 0
+}
+
+// DetectClipping 查找每个声道上连续处于或接近满幅的样本段
+// threshold 为判定满幅的绝对值（如 0.99），minRun 为构成削波的最少连续样本数
+func DetectClipping(segment *audio.AudioSegment, threshold float64, minRun int) (*ClippingReport, error) {
+	if threshold <= 0 {
+		return nil, errors.New("threshold must be positive")
+	}
+	if minRun <= 0 {
+		return nil, errors.New("minimum run length must be positive")
+	}
+
+	sampleRate := segment.SampleRate()
+	channels := segment.Channels()
+	report := &ClippingReport{
+		ClippedSamples: make([]int, channels),
+		RunCounts:      make([]int, channels),
+	}
+
+	toDuration := func(frame int) time.Duration {
+		return time.Duration(float64(frame) / float64(sampleRate) * float64(time.Second))
+	}
+
+	for ch, samples := range segment.SplitChannels() {
+		for i := 0; i < len(samples); i++ {
+			if math.Abs(samples[i]) < threshold {
+				continue
+			}
+
+			// 同一段削波的样本应同号
+			end := i
+			for end+1 < len(samples) && math.Abs(samples[end+1]) >= threshold &&
+				math.Signbit(samples[end+1]) == math.Signbit(samples[i]) {
+				end++
+			}
+
+			if length := end - i + 1; length >= minRun {
+				report.Runs = append(report.Runs, ClippedRun{
+					Channel: ch,
+					Start:   i,
+					Length:  length,
+					From:    toDuration(i),
+					To:      toDuration(end + 1),
+				})
+				report.ClippedSamples[ch] += length
+				report.RunCounts[ch]++
+			}
+			i = end
+		}
+	}
+
+	return report, nil
+}
+
+// Declip 用AR插值重建被削平的波峰（超过 5ms 的区段使用三次插值），重建值不会低于原削波电平；
+// headroom 为修复后整体衰减的分贝数，为重建出的超满幅波峰预留余量
+func Declip(segment *audio.AudioSegment, threshold float64, headroom float64) (*audio.AudioSegment, error) {
+	if headroom < 0 {
+		return nil, errors.New("headroom must not be negative")
+	}
+
+	report, err := DetectClipping(segment, threshold, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	maxLength := int(clipMaxDuration.Seconds() * float64(segment.SampleRate()))
+	data := segment.SplitChannels()
+	for _, run := range report.Runs {
+		samples := data[run.Channel]
+		clipped := make([]float64, run.Length)
+		copy(clipped, samples[run.Start:run.Start+run.Length])
+
+		if run.Length > maxLength || !interpolateGap(samples, run.Start, run.Length, clipAROrder) {
+			cubicInterpolateGap(samples, run.Start, run.Length)
+		}
+
+		// 真实波峰的幅度至少等于削波电平，且与削波样本同号
+		for i, level := range clipped {
+			pos := run.Start + i
+			if math.Signbit(samples[pos]) != math.Signbit(level) || math.Abs(samples[pos]) < math.Abs(level) {
+				samples[pos] = level
+			}
+		}
+	}
+
+	gain := math.Pow(10, -headroom/20.0)
+	for _, samples := range data {
+		for i := range samples {
+			samples[i] *= gain
+		}
+	}
+
+	result, err := audio.NewAudioSegmentFromChannels(data, segment.SampleRate(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// cubicInterpolateGap 用区间两侧各两个样本拟合三次多项式（Lagrange），原地重建 [start, start+length)
+func cubicInterpolateGap(samples []float64, start, length int) {
+	end := start + length
+	if start < 2 || end+2 > len(samples) {
+		return
+	}
+
+	xs := []float64{float64(start - 2), float64(start - 1), float64(end), float64(end + 1)}
+	ys := []float64{samples[start-2], samples[start-1], samples[end], samples[end+1]}
+
+	for pos := start; pos < end; pos++ {
+		x := float64(pos)
+		var value float64
+		for i := range xs {
+			term := ys[i]
+			for j := range xs {
+				if i != j {
+					term *= (x - xs[j]) / (xs[i] - xs[j])
+				}
+			}
+			value += term
+		}
+		samples[pos] = value
+	}
+}
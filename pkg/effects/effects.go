@@ -27,7 +27,11 @@ func FadeIn(segment *audio.AudioSegment, duration time.Duration) (*audio.AudioSe
 		newSamples[i] = samples[i] * factor
 	}
 
-	return audio.NewAudioSegment(newSamples, sampleRate, channels, segment.BitDepth())
+	result, err := audio.NewAudioSegment(newSamples, sampleRate, channels, segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
 }
 
 // FadeOut 实现音频淡出效果
@@ -51,7 +55,11 @@ func FadeOut(segment *audio.AudioSegment, duration time.Duration) (*audio.AudioS
 		newSamples[startIndex+i] = samples[startIndex+i] * factor
 	}
 
-	return audio.NewAudioSegment(newSamples, sampleRate, channels, segment.BitDepth())
+	result, err := audio.NewAudioSegment(newSamples, sampleRate, channels, segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
 }
 
 // Normalize 标准化音频音量
@@ -80,7 +88,11 @@ func Normalize(segment *audio.AudioSegment) (*audio.AudioSegment, error) {
 		newSamples[i] = sample / maxAmp
 	}
 
-	return audio.NewAudioSegment(newSamples, sampleRate, channels, segment.BitDepth())
+	result, err := audio.NewAudioSegment(newSamples, sampleRate, channels, segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
 }
 
 // AdjustVolume 调整音频音量
@@ -98,5 +110,19 @@ func AdjustVolume(segment *audio.AudioSegment, dB float64) (*audio.AudioSegment,
 		newSamples[i] = sample * factor
 	}
 
-	return audio.NewAudioSegment(newSamples, sampleRate, channels, segment.BitDepth())
+	result, err := audio.NewAudioSegment(newSamples, sampleRate, channels, segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// inherit 让效果器的输出沿用输入的元数据标签和声道布局（声道数不变时），
+// 避免标签丢失、非默认布局（如 5.1(side)）在重建音频段时被重置为默认布局
+func inherit(segment, result *audio.AudioSegment) (*audio.AudioSegment, error) {
+	result = result.WithTags(segment.Tags())
+	if result.Channels() != segment.Channels() || segment.Layout() == 0 {
+		return result, nil
+	}
+	return result.WithLayout(segment.Layout())
 }
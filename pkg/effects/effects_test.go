@@ -0,0 +1,469 @@
+package effects
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/audio"
+)
+
+// sineSamples 生成指定频率和幅度的正弦波
+func sineSamples(freq, amplitude float64, sampleRate, length int) []float64 {
+	samples := make([]float64, length)
+	for i := range samples {
+		samples[i] = amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+	}
+	return samples
+}
+
+func TestReduceNoise(t *testing.T) {
+	sampleRate := 44100
+	rng := rand.New(rand.NewSource(1))
+
+	// 前半段只有噪声，后半段是正弦波加噪声
+	clean := make([]float64, sampleRate)
+	copy(clean[sampleRate/2:], sineSamples(440, 0.5, sampleRate, sampleRate/2))
+	noisy := make([]float64, len(clean))
+	for i := range noisy {
+		noisy[i] = clean[i] + 0.02*rng.NormFloat64()
+	}
+
+	segment, err := audio.NewAudioSegment(noisy, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	processed, err := ReduceNoise(segment, nil, 1.5, 0.5)
+	if err != nil {
+		t.Fatalf("failed to reduce noise: %v", err)
+	}
+
+	var before, after float64
+	for i, sample := range processed.Samples() {
+		before += (noisy[i] - clean[i]) * (noisy[i] - clean[i])
+		after += (sample - clean[i]) * (sample - clean[i])
+	}
+	if after >= before/2 {
+		t.Errorf("expected noise energy to drop by half, before %f, after %f", before, after)
+	}
+
+	// strength 为 0 时应完整重建原始信号
+	unchanged, err := ReduceNoise(segment, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to reduce noise: %v", err)
+	}
+	for i, sample := range unchanged.Samples() {
+		if math.Abs(sample-noisy[i]) > 1e-9 {
+			t.Fatalf("sample %d: expected %f, got %f", i, noisy[i], sample)
+		}
+	}
+
+	// 噪声样本的采样率必须与音频一致
+	profile, err := audio.NewAudioSegment(noisy[:sampleRate/2], 48000, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	if _, err := ReduceNoise(segment, profile, 1.5, 0.5); err == nil {
+		t.Error("expected error for noise profile with a different sample rate")
+	}
+}
+
+func TestRemoveDC(t *testing.T) {
+	// 立体声，两个声道带有不同的直流偏移
+	samples := []float64{0.3, -0.2, 0.5, -0.4, 0.1, 0.0, 0.3, -0.2}
+	segment, err := audio.NewAudioSegment(samples, 8000, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	processed, err := RemoveDC(segment)
+	if err != nil {
+		t.Fatalf("failed to remove dc: %v", err)
+	}
+
+	for ch, data := range processed.SplitChannels() {
+		var mean float64
+		for _, sample := range data {
+			mean += sample
+		}
+		if math.Abs(mean) > 1e-12 {
+			t.Errorf("channel %d: expected zero mean, got %f", ch, mean/float64(len(data)))
+		}
+	}
+}
+
+func TestRemoveHum(t *testing.T) {
+	sampleRate := 8000
+	length := sampleRate * 2
+
+	tone := sineSamples(440, 0.3, sampleRate, length)
+	hum := sineSamples(60, 0.2, sampleRate, length)
+	harmonic := sineSamples(180, 0.1, sampleRate, length)
+	samples := make([]float64, length)
+	for i := range samples {
+		samples[i] = tone[i] + hum[i] + harmonic[i]
+	}
+
+	segment, err := audio.NewAudioSegment(samples, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	if freq := DetectHum(segment, 4); freq != 60 {
+		t.Errorf("expected 60 Hz hum, got %f", freq)
+	}
+
+	processed, err := RemoveHum(segment, 0, 4)
+	if err != nil {
+		t.Fatalf("failed to remove hum: %v", err)
+	}
+
+	// 跳过滤波器的起振阶段
+	tail := processed.Samples()[sampleRate:]
+	if power := goertzelPower(tail, 60, sampleRate); power > goertzelPower(hum[sampleRate:], 60, sampleRate)*0.01 {
+		t.Errorf("60 Hz hum was not removed, residual power %f", power)
+	}
+	if power, expected := goertzelPower(tail, 440, sampleRate), goertzelPower(tone[sampleRate:], 440, sampleRate); power < expected*0.9 {
+		t.Errorf("440 Hz tone was attenuated, power %f, expected %f", power, expected)
+	}
+}
+
+func TestDeclick(t *testing.T) {
+	sampleRate := 44100
+	rng := rand.New(rand.NewSource(2))
+
+	clean := sineSamples(440, 0.3, sampleRate, sampleRate/2)
+	overtone := sineSamples(1230, 0.1, sampleRate, sampleRate/2)
+	for i := range clean {
+		clean[i] += overtone[i] + 0.001*rng.NormFloat64()
+	}
+
+	damaged := make([]float64, len(clean))
+	copy(damaged, clean)
+	positions := []int{5000, 12345, 20000}
+	for _, pos := range positions {
+		damaged[pos] += 0.6
+		damaged[pos+1] -= 0.4
+	}
+
+	segment, err := audio.NewAudioSegment(damaged, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	clicks, err := DetectClicks(segment, 8)
+	if err != nil {
+		t.Fatalf("failed to detect clicks: %v", err)
+	}
+	for _, pos := range positions {
+		found := false
+		for _, click := range clicks {
+			if pos >= click.Start && pos < click.Start+click.Length {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("click at sample %d was not detected", pos)
+		}
+	}
+
+	repaired, err := Declick(segment, 8)
+	if err != nil {
+		t.Fatalf("failed to declick: %v", err)
+	}
+	for _, pos := range positions {
+		for i := pos - 2; i <= pos+3; i++ {
+			if diff := math.Abs(repaired.Samples()[i] - clean[i]); diff > 0.02 {
+				t.Errorf("sample %d: repaired value differs from clean signal by %f", i, diff)
+			}
+		}
+	}
+}
+
+func TestDeclip(t *testing.T) {
+	sampleRate := 44100
+	rng := rand.New(rand.NewSource(3))
+
+	clean := sineSamples(220, 1.2, sampleRate, sampleRate/4)
+	clipped := make([]float64, len(clean))
+	for i, sample := range clean {
+		clipped[i] = math.Max(-1, math.Min(1, sample)) + 0.0001*rng.NormFloat64()
+		if math.Abs(sample) >= 1 {
+			clipped[i] = math.Copysign(1, sample)
+		}
+	}
+
+	segment, err := audio.NewAudioSegment(clipped, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	report, err := DetectClipping(segment, 0.999, 3)
+	if err != nil {
+		t.Fatalf("failed to detect clipping: %v", err)
+	}
+	if !report.Clipped() || report.RunCounts[0] == 0 {
+		t.Fatal("expected clipped runs to be detected")
+	}
+
+	repaired, err := Declip(segment, 0.999, 0)
+	if err != nil {
+		t.Fatalf("failed to declip: %v", err)
+	}
+
+	var before, after float64
+	for i, sample := range repaired.Samples() {
+		before += (clipped[i] - clean[i]) * (clipped[i] - clean[i])
+		after += (sample - clean[i]) * (sample - clean[i])
+	}
+	if after >= before/4 {
+		t.Errorf("expected reconstruction error to drop, before %f, after %f", before, after)
+	}
+}
+
+func TestDeclipLongRuns(t *testing.T) {
+	// 重度限幅的低频信号：每段削波约 2000 个样本
+	sampleRate := 48000
+	clean := sineSamples(10, 3, sampleRate, sampleRate)
+	clipped := make([]float64, len(clean))
+	for i, sample := range clean {
+		clipped[i] = math.Max(-1, math.Min(1, sample))
+	}
+	segment, err := audio.NewAudioSegment(clipped, sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	start := time.Now()
+	repaired, err := Declip(segment, 0.999, 0)
+	if err != nil {
+		t.Fatalf("failed to declip: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected declipping to finish within 2s, took %v", elapsed)
+	}
+
+	// 长区段用三次插值重建，波峰应高于削波电平
+	var peak float64
+	for _, sample := range repaired.Samples() {
+		peak = math.Max(peak, math.Abs(sample))
+	}
+	if peak <= 1 {
+		t.Errorf("expected reconstructed peaks above the clipping level, got %f", peak)
+	}
+}
+
+func TestPan(t *testing.T) {
+	segment, err := audio.NewAudioSegment([]float64{0.5, -0.5, 1.0}, 44100, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		position  float64
+		law       PanLaw
+		leftGain  float64
+		rightGain float64
+	}{
+		{name: "Center 3dB", position: 0, law: PanLaw3dB, leftGain: math.Sqrt(0.5), rightGain: math.Sqrt(0.5)},
+		{name: "Center 6dB", position: 0, law: PanLaw6dB, leftGain: 0.5, rightGain: 0.5},
+		{name: "Center 0dB", position: 0, law: PanLaw0dB, leftGain: 1, rightGain: 1},
+		{name: "Hard Left", position: -1, law: PanLaw3dB, leftGain: 1, rightGain: 0},
+		{name: "Hard Right", position: 1, law: PanLaw4_5dB, leftGain: 0, rightGain: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panned, err := Pan(segment, tt.position, tt.law)
+			if err != nil {
+				t.Fatalf("failed to pan: %v", err)
+			}
+			if panned.Channels() != 2 {
+				t.Fatalf("expected stereo output, got %d channels", panned.Channels())
+			}
+
+			data := panned.SplitChannels()
+			for i, sample := range segment.Samples() {
+				if math.Abs(data[0][i]-sample*tt.leftGain) > 1e-9 {
+					t.Errorf("left sample %d: expected %f, got %f", i, sample*tt.leftGain, data[0][i])
+				}
+				if math.Abs(data[1][i]-sample*tt.rightGain) > 1e-9 {
+					t.Errorf("right sample %d: expected %f, got %f", i, sample*tt.rightGain, data[1][i])
+				}
+			}
+		})
+	}
+}
+
+func TestMidSide(t *testing.T) {
+	samples := []float64{0.5, 0.1, -0.3, 0.4, 0.2, -0.2}
+	segment, err := audio.NewAudioSegment(samples, 44100, 2, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	ms, err := ToMidSide(segment)
+	if err != nil {
+		t.Fatalf("failed to convert to mid/side: %v", err)
+	}
+	restored, err := FromMidSide(ms)
+	if err != nil {
+		t.Fatalf("failed to convert from mid/side: %v", err)
+	}
+	for i, sample := range restored.Samples() {
+		if math.Abs(sample-samples[i]) > 1e-12 {
+			t.Errorf("sample %d: expected %f, got %f", i, samples[i], sample)
+		}
+	}
+
+	// 宽度为 0 时左右声道相同
+	mono, err := StereoWidth(segment, 0)
+	if err != nil {
+		t.Fatalf("failed to adjust stereo width: %v", err)
+	}
+	data := mono.SplitChannels()
+	for i := range data[0] {
+		if math.Abs(data[0][i]-data[1][i]) > 1e-12 {
+			t.Errorf("frame %d: expected identical channels, got %f and %f", i, data[0][i], data[1][i])
+		}
+	}
+}
+
+func TestTimeStretch(t *testing.T) {
+	sampleRate := 16000
+	segment, err := audio.NewAudioSegment(sineSamples(440, 0.5, sampleRate, sampleRate), sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	for _, factor := range []float64{0.5, 0.8, 1.5} {
+		stretched, err := TimeStretch(segment, factor)
+		if err != nil {
+			t.Fatalf("failed to stretch: %v", err)
+		}
+		samples := stretched.Samples()
+		if expected := int(float64(sampleRate) * factor); len(samples) != expected {
+			t.Fatalf("factor %f: expected %d samples, got %d", factor, expected, len(samples))
+		}
+
+		// 音高不变：中间部分 440Hz 的能量应远高于相邻半音
+		middle := samples[len(samples)/4 : 3*len(samples)/4]
+		if goertzelPower(middle, 440, sampleRate) < 20*goertzelPower(middle, 466, sampleRate) {
+			t.Errorf("factor %f: expected pitch to be preserved", factor)
+		}
+	}
+}
+
+// syllables 依次拼接不同频率、不同时长的音节，音节间插入短暂静音
+func syllables(freqs []float64, lengths []int, sampleRate int) []float64 {
+	var samples []float64
+	gap := make([]float64, sampleRate/20)
+	for i, freq := range freqs {
+		samples = append(samples, sineSamples(freq, 0.5, sampleRate, lengths[i])...)
+		samples = append(samples, gap...)
+	}
+	return samples
+}
+
+func TestConformToGuide(t *testing.T) {
+	sampleRate := 16000
+	freqs := []float64{300, 700, 1200, 500}
+	guideLengths := []int{4000, 8000, 3000, 6000}
+	takeLengths := []int{6000, 5000, 5000, 4000}
+
+	guide, err := audio.NewAudioSegment(syllables(freqs, guideLengths, sampleRate), sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	take, err := audio.NewAudioSegment(syllables(freqs, takeLengths, sampleRate), sampleRate, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+
+	conformed, err := ConformToGuide(take, guide)
+	if err != nil {
+		t.Fatalf("failed to conform take: %v", err)
+	}
+	if conformed.Duration() != guide.Duration() {
+		t.Fatalf("expected duration %v, got %v", guide.Duration(), conformed.Duration())
+	}
+
+	// 每个导轨音节的中心处，对齐后的录音应为同一频率
+	samples := conformed.Samples()
+	start := 0
+	for i, freq := range freqs {
+		center := start + guideLengths[i]/2
+		window := samples[center-800 : center+800]
+		power := goertzelPower(window, freq, sampleRate)
+		for j, other := range freqs {
+			if j != i && goertzelPower(window, other, sampleRate) > power {
+				t.Errorf("syllable %d: expected %fHz to dominate, got %fHz", i, freq, other)
+			}
+		}
+		start += guideLengths[i] + sampleRate/20
+	}
+}
+
+func TestEffectsPreserveFormat(t *testing.T) {
+	sampleRate := 8000
+	frames := sampleRate / 2
+	data := make([][]float64, 6)
+	for ch := range data {
+		data[ch] = sineSamples(220*float64(ch+1), 0.3, sampleRate, frames)
+	}
+	source, err := audio.NewAudioSegmentFromChannels(data, sampleRate, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	segment, err := source.WithTags(map[string]string{"title": "Scene 4"}).WithLayout(audio.Layout5_1Side)
+	if err != nil {
+		t.Fatalf("failed to set layout: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		effect func(*audio.AudioSegment) (*audio.AudioSegment, error)
+	}{
+		{"FadeIn", func(s *audio.AudioSegment) (*audio.AudioSegment, error) { return FadeIn(s, 100*time.Millisecond) }},
+		{"Normalize", Normalize},
+		{"AdjustVolume", func(s *audio.AudioSegment) (*audio.AudioSegment, error) { return AdjustVolume(s, -3) }},
+		{"RemoveDC", RemoveDC},
+		{"RemoveHum", func(s *audio.AudioSegment) (*audio.AudioSegment, error) { return RemoveHum(s, 50, 3) }},
+		{"Declick", func(s *audio.AudioSegment) (*audio.AudioSegment, error) { return Declick(s, 8) }},
+		{"Declip", func(s *audio.AudioSegment) (*audio.AudioSegment, error) { return Declip(s, 0.25, 0) }},
+		{"ReduceNoise", func(s *audio.AudioSegment) (*audio.AudioSegment, error) { return ReduceNoise(s, nil, 1, 0.5) }},
+		{"TimeStretch", func(s *audio.AudioSegment) (*audio.AudioSegment, error) { return TimeStretch(s, 1.2) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.effect(segment)
+			if err != nil {
+				t.Fatalf("failed to apply effect: %v", err)
+			}
+			if result.Layout() != audio.Layout5_1Side {
+				t.Errorf("expected layout %v, got %v", audio.Layout5_1Side, result.Layout())
+			}
+			if result.Tag("title") != "Scene 4" {
+				t.Errorf("expected tags to be kept, got %v", result.Tags())
+			}
+		})
+	}
+}
+
+func TestPanKeepsTags(t *testing.T) {
+	mono, err := audio.NewAudioSegment(sineSamples(440, 0.5, 8000, 800), 8000, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create audio segment: %v", err)
+	}
+	panned, err := Pan(mono.WithTags(map[string]string{"artist": "Studio"}), 0.5, PanLaw3dB)
+	if err != nil {
+		t.Fatalf("failed to pan: %v", err)
+	}
+	if panned.Channels() != 2 || panned.Tag("artist") != "Studio" {
+		t.Errorf("expected stereo output with tags, got %d channels and %v", panned.Channels(), panned.Tags())
+	}
+}
@@ -0,0 +1,47 @@
+package effects
+
+import "math"
+
+// biquad 二阶IIR滤波器（Direct Form I）
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+
+	x1, x2 float64
+	y1, y2 float64
+}
+
+// newNotch 创建中心频率为 freq、品质因数为 q 的陷波滤波器
+func newNotch(freq, q float64, sampleRate int) *biquad {
+	w0 := 2 * math.Pi * freq / float64(sampleRate)
+	alpha := math.Sin(w0) / (2 * q)
+	cosw0 := math.Cos(w0)
+	a0 := 1 + alpha
+
+	return &biquad{
+		b0: 1 / a0,
+		b1: -2 * cosw0 / a0,
+		b2: 1 / a0,
+		a1: -2 * cosw0 / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+// process 滤波单个样本
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// goertzelPower 使用 Goertzel 算法计算单个频率上的能量
+func goertzelPower(samples []float64, freq float64, sampleRate int) float64 {
+	coeff := 2 * math.Cos(2*math.Pi*freq/float64(sampleRate))
+	var s1, s2 float64
+	for _, x := range samples {
+		s0 := x + coeff*s1 - s2
+		s2, s1 = s1, s0
+	}
+	return s1*s1 + s2*s2 - coeff*s1*s2
+}
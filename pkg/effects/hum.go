@@ -0,0 +1,101 @@
+package effects
+
+import (
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+const (
+	// 陷波滤波器的品质因数，越大陷波越窄
+	humNotchQ = 30.0
+	// 自动检测嗡声时分析的最大样本数（单声道）
+	humDetectLength = 1 << 18
+)
+
+// RemoveDC 去除每个声道的直流偏移（减去声道均值）
+func RemoveDC(segment *audio.AudioSegment) (*audio.AudioSegment, error) {
+	data := segment.SplitChannels()
+	for _, samples := range data {
+		var mean float64
+		for _, sample := range samples {
+			mean += sample
+		}
+		mean /= float64(len(samples))
+
+		for i := range samples {
+			samples[i] -= mean
+		}
+	}
+
+	result, err := audio.NewAudioSegmentFromChannels(data, segment.SampleRate(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// RemoveHum 使用一组窄陷波滤波器去除工频嗡声及其谐波
+// fundamental 为工频（50 或 60 Hz），传 0 时自动检测；harmonics 为需要去除的谐波个数（含基频）
+func RemoveHum(segment *audio.AudioSegment, fundamental float64, harmonics int) (*audio.AudioSegment, error) {
+	if fundamental < 0 {
+		return nil, errors.New("fundamental must not be negative")
+	}
+	if harmonics <= 0 {
+		return nil, errors.New("harmonics must be positive")
+	}
+
+	sampleRate := segment.SampleRate()
+	data := segment.SplitChannels()
+
+	if fundamental == 0 {
+		fundamental = DetectHum(segment, harmonics)
+	}
+
+	for _, samples := range data {
+		for h := 1; h <= harmonics; h++ {
+			freq := fundamental * float64(h)
+			if freq >= float64(sampleRate)/2 {
+				break
+			}
+			notch := newNotch(freq, humNotchQ, sampleRate)
+			for i, sample := range samples {
+				samples[i] = notch.process(sample)
+			}
+		}
+	}
+
+	result, err := audio.NewAudioSegmentFromChannels(data, sampleRate, segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// DetectHum 比较 50 Hz 与 60 Hz 谐波系列的能量，返回更可能的工频
+func DetectHum(segment *audio.AudioSegment, harmonics int) float64 {
+	sampleRate := segment.SampleRate()
+
+	// 混合为单声道后分析
+	data := segment.SplitChannels()
+	length := len(data[0])
+	if length > humDetectLength {
+		length = humDetectLength
+	}
+	mono := make([]float64, length)
+	for _, samples := range data {
+		for i := 0; i < length; i++ {
+			mono[i] += samples[i] / float64(len(data))
+		}
+	}
+
+	var power50, power60 float64
+	for h := 1; h <= harmonics; h++ {
+		power50 += goertzelPower(mono, 50*float64(h), sampleRate)
+		power60 += goertzelPower(mono, 60*float64(h), sampleRate)
+	}
+
+	if power60 > power50 {
+		return 60
+	}
+	return 50
+}
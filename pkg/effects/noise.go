@@ -0,0 +1,134 @@
+package effects
+
+import (
+	"math"
+	"sort"
+
+	"github.com/HiChen85/godub/pkg/analysis"
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// 自动估计噪声时取最安静帧的比例
+const noiseQuietRatio = 0.1
+
+// noiseSTFTOptions 降噪使用的STFT参数
+var noiseSTFTOptions = analysis.STFTOptions{
+	FrameSize: 2048,
+	HopSize:   512,
+	Window:    analysis.Hann,
+	Center:    true,
+}
+
+// ReduceNoise 使用STFT谱减法降低稳态噪声（嗡声、嘶声）
+// noiseProfile 为仅包含噪声的音频段，为 nil 时从最安静的帧中自动估计噪声谱；
+// strength 为噪声谱的减除倍数（通常为1~2），smoothing 为增益在时间上的平滑系数，取值 [0, 1)
+func ReduceNoise(segment *audio.AudioSegment, noiseProfile *audio.AudioSegment, strength, smoothing float64) (*audio.AudioSegment, error) {
+	if strength < 0 {
+		return nil, errors.New("strength must not be negative")
+	}
+	if smoothing < 0 || smoothing >= 1 {
+		return nil, errors.New("smoothing must be in [0, 1)")
+	}
+	if noiseProfile != nil && noiseProfile.Channels() != segment.Channels() && noiseProfile.Channels() != 1 {
+		return nil, errors.New("noise profile must be mono or have the same channel count as the segment")
+	}
+	// 采样率不同时噪声谱的频点对应不同频率
+	if noiseProfile != nil && noiseProfile.SampleRate() != segment.SampleRate() {
+		return nil, errors.New("noise profile must have the same sample rate as the segment")
+	}
+
+	specs, err := analysis.STFT(segment, noiseSTFTOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var profileSpecs []*analysis.Spectrogram
+	if noiseProfile != nil {
+		if profileSpecs, err = analysis.STFT(noiseProfile, noiseSTFTOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	for ch, spec := range specs {
+		var noise []float64
+		if profileSpecs != nil {
+			noise = averageSpectrum(profileSpecs[ch%len(profileSpecs)].Magnitude)
+		} else {
+			noise = quietestSpectrum(spec.Magnitude)
+		}
+		spectralSubtract(spec, noise, strength, smoothing)
+	}
+
+	result, err := analysis.ISTFT(specs, segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// averageSpectrum 计算所有帧的平均幅度谱
+func averageSpectrum(mags [][]float64) []float64 {
+	if len(mags) == 0 {
+		return nil
+	}
+	avg := make([]float64, len(mags[0]))
+	for _, mag := range mags {
+		for k, v := range mag {
+			avg[k] += v
+		}
+	}
+	for k := range avg {
+		avg[k] /= float64(len(mags))
+	}
+	return avg
+}
+
+// quietestSpectrum 取能量最低的若干帧的平均幅度谱作为噪声估计
+func quietestSpectrum(mags [][]float64) []float64 {
+	if len(mags) == 0 {
+		return nil
+	}
+
+	type frameEnergy struct {
+		index  int
+		energy float64
+	}
+	energies := make([]frameEnergy, len(mags))
+	for i, mag := range mags {
+		var e float64
+		for _, v := range mag {
+			e += v * v
+		}
+		energies[i] = frameEnergy{index: i, energy: e}
+	}
+	sort.Slice(energies, func(i, j int) bool {
+		return energies[i].energy < energies[j].energy
+	})
+
+	count := int(math.Ceil(float64(len(mags)) * noiseQuietRatio))
+	quiet := make([][]float64, count)
+	for i := 0; i < count; i++ {
+		quiet[i] = mags[energies[i].index]
+	}
+	return averageSpectrum(quiet)
+}
+
+// spectralSubtract 原地对幅度谱做谱减，增益在时间方向平滑以减少“音乐噪声”
+func spectralSubtract(spec *analysis.Spectrogram, noise []float64, strength, smoothing float64) {
+	gains := make([]float64, spec.Bins())
+	for k := range gains {
+		gains[k] = 1
+	}
+
+	for _, frame := range spec.Magnitude {
+		for k, mag := range frame {
+			gain := 1.0
+			if mag > 0 && noise != nil {
+				gain = math.Max(0, 1-strength*noise[k]/mag)
+			}
+			gains[k] = smoothing*gains[k] + (1-smoothing)*gain
+			frame[k] = mag * gains[k]
+		}
+	}
+}
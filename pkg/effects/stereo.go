@@ -0,0 +1,187 @@
+package effects
+
+import (
+	"math"
+
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+// PanLaw 声像定律，决定居中时每个声道的衰减量
+type PanLaw int
+
+const (
+	// PanLaw3dB 恒定功率定律（正弦/余弦），居中时每个声道 -3dB
+	PanLaw3dB PanLaw = iota
+	// PanLaw6dB 线性定律（恒定电压），居中时每个声道 -6dB
+	PanLaw6dB
+	// PanLaw4_5dB 折中定律，居中时每个声道 -4.5dB
+	PanLaw4_5dB
+	// PanLaw0dB 居中不衰减，只衰减相反一侧
+	PanLaw0dB
+)
+
+// panGains 计算声像位置 position（-1 为最左，1 为最右）对应的左右增益
+func panGains(position float64, law PanLaw) (float64, float64) {
+	theta := (position + 1) * math.Pi / 4
+	switch law {
+	case PanLaw6dB:
+		return (1 - position) / 2, (1 + position) / 2
+	case PanLaw4_5dB:
+		return math.Sqrt(math.Cos(theta) * (1 - position) / 2), math.Sqrt(math.Sin(theta) * (1 + position) / 2)
+	case PanLaw0dB:
+		return math.Min(1, 1-position), math.Min(1, 1+position)
+	default:
+		return math.Cos(theta), math.Sin(theta)
+	}
+}
+
+// Pan 按指定声像定律将音频放置到立体声声场中，单声道输入会被扩展为立体声
+// position 取值 [-1, 1]，-1 为最左，0 为居中，1 为最右
+func Pan(segment *audio.AudioSegment, position float64, law PanLaw) (*audio.AudioSegment, error) {
+	if position < -1 || position > 1 {
+		return nil, errors.New("pan position must be in [-1, 1]")
+	}
+
+	var left, right []float64
+	switch segment.Channels() {
+	case 1:
+		mono := segment.Samples()
+		left = make([]float64, len(mono))
+		right = make([]float64, len(mono))
+		copy(left, mono)
+		copy(right, mono)
+	case 2:
+		data := segment.SplitChannels()
+		left, right = data[0], data[1]
+	default:
+		return nil, errors.New("pan requires a mono or stereo segment")
+	}
+
+	leftGain, rightGain := panGains(position, law)
+	for i := range left {
+		left[i] *= leftGain
+		right[i] *= rightGain
+	}
+
+	result, err := audio.NewAudioSegmentFromChannels([][]float64{left, right}, segment.SampleRate(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// Balance 调整立体声左右平衡，居中时不改变音量，偏向一侧时衰减另一侧
+// position 取值 [-1, 1]
+func Balance(segment *audio.AudioSegment, position float64) (*audio.AudioSegment, error) {
+	if segment.Channels() != 2 {
+		return nil, errors.New("balance requires a stereo segment")
+	}
+	return Pan(segment, position, PanLaw0dB)
+}
+
+// StereoWidth 通过缩放侧信号调整立体声宽度
+// width 为 0 时变为单声道，1 时不变，大于 1 时展宽
+func StereoWidth(segment *audio.AudioSegment, width float64) (*audio.AudioSegment, error) {
+	if width < 0 {
+		return nil, errors.New("width must not be negative")
+	}
+
+	return ApplyMidSide(segment, nil, func(side *audio.AudioSegment) (*audio.AudioSegment, error) {
+		return scaleSegment(side, width)
+	})
+}
+
+// ToMidSide 将立体声音频转换为中/侧信号，返回的双声道音频中声道0为中信号，声道1为侧信号
+func ToMidSide(segment *audio.AudioSegment) (*audio.AudioSegment, error) {
+	if segment.Channels() != 2 {
+		return nil, errors.New("mid/side conversion requires a stereo segment")
+	}
+
+	data := segment.SplitChannels()
+	left, right := data[0], data[1]
+	for i := range left {
+		mid := (left[i] + right[i]) / 2
+		side := (left[i] - right[i]) / 2
+		left[i], right[i] = mid, side
+	}
+
+	result, err := audio.NewAudioSegmentFromChannels(data, segment.SampleRate(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// FromMidSide 将中/侧信号转换回左右立体声
+func FromMidSide(segment *audio.AudioSegment) (*audio.AudioSegment, error) {
+	if segment.Channels() != 2 {
+		return nil, errors.New("mid/side conversion requires a two-channel segment")
+	}
+
+	data := segment.SplitChannels()
+	mid, side := data[0], data[1]
+	for i := range mid {
+		left := mid[i] + side[i]
+		right := mid[i] - side[i]
+		mid[i], side[i] = left, right
+	}
+
+	result, err := audio.NewAudioSegmentFromChannels(data, segment.SampleRate(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// ApplyMidSide 分别对立体声的中信号和侧信号（单声道音频段）应用处理函数，为 nil 的一路保持不变
+// 处理函数不能改变样本数
+func ApplyMidSide(segment *audio.AudioSegment, mid, side func(*audio.AudioSegment) (*audio.AudioSegment, error)) (*audio.AudioSegment, error) {
+	ms, err := ToMidSide(segment)
+	if err != nil {
+		return nil, err
+	}
+
+	data := ms.SplitChannels()
+	for i, process := range []func(*audio.AudioSegment) (*audio.AudioSegment, error){mid, side} {
+		if process == nil {
+			continue
+		}
+		channel, err := audio.NewAudioSegment(data[i], segment.SampleRate(), 1, segment.BitDepth())
+		if err != nil {
+			return nil, err
+		}
+		processed, err := process(channel)
+		if err != nil {
+			return nil, err
+		}
+		if processed.Channels() != 1 || len(processed.Samples()) != len(data[i]) {
+			return nil, errors.New("mid/side processor must return a mono segment of the same length")
+		}
+		data[i] = processed.Samples()
+	}
+
+	processed, err := audio.NewAudioSegmentFromChannels(data, segment.SampleRate(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	result, err := FromMidSide(processed)
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
+
+// scaleSegment 将所有样本乘以线性增益
+func scaleSegment(segment *audio.AudioSegment, gain float64) (*audio.AudioSegment, error) {
+	samples := segment.Samples()
+	newSamples := make([]float64, len(samples))
+	for i, sample := range samples {
+		newSamples[i] = sample * gain
+	}
+	result, err := audio.NewAudioSegment(newSamples, segment.SampleRate(), segment.Channels(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
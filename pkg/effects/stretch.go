@@ -0,0 +1,189 @@
+package effects
+
+import (
+	"math"
+	"time"
+
+	"github.com/HiChen85/godub/pkg/analysis"
+	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/pkg/errors"
+)
+
+const (
+	// WSOLA 帧长（秒），帧移为帧长的一半，相似度搜索范围为帧移的一半
+	wsolaFrameTime = 0.03
+	// 时间规整映射的平滑半径和分段间隔（帧）
+	warpSmoothing      = 5
+	warpAnchorInterval = 10
+)
+
+// TimeStretch 在不改变音高的情况下改变时长（WSOLA），factor 为输出时长与输入时长之比
+func TimeStretch(segment *audio.AudioSegment, factor float64) (*audio.AudioSegment, error) {
+	if segment == nil {
+		return nil, errors.New("segment cannot be nil")
+	}
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return nil, errors.New("stretch factor must be positive")
+	}
+
+	frames := len(segment.Samples()) / segment.Channels()
+	return wsola(segment, int(math.Round(float64(frames)*factor)), func(t float64) float64 {
+		return t / factor
+	})
+}
+
+// TimeWarp 按规整路径对录音做分段时间伸缩，使其节奏跟随导轨，输出时长为 duration
+func TimeWarp(take *audio.AudioSegment, path *analysis.WarpPath, duration time.Duration) (*audio.AudioSegment, error) {
+	if take == nil || path == nil {
+		return nil, errors.New("take and path cannot be nil")
+	}
+	if duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+	if path.GuideFrames == 0 || path.FrameDuration <= 0 || path.TakeFrameDuration <= 0 {
+		return nil, errors.New("empty warp path")
+	}
+
+	anchors := warpAnchors(path.Mapping())
+	sampleRate := float64(take.SampleRate())
+	guideFramesPerSample := 1 / (path.FrameDuration.Seconds() * sampleRate)
+	takeSamplesPerFrame := path.TakeFrameDuration.Seconds() * sampleRate
+	// 一个导轨帧对应的录音帧数（两段音频采样率不同时帧时长不同）
+	speed := path.FrameDuration.Seconds() / path.TakeFrameDuration.Seconds()
+	last := len(anchors) - 1
+
+	return wsola(take, int(duration.Seconds()*sampleRate), func(t float64) float64 {
+		guide := t * guideFramesPerSample / warpAnchorInterval
+		var takeFrame float64
+		switch {
+		case last == 0 || guide <= 0:
+			takeFrame = anchors[0] + guide*warpAnchorInterval*speed
+		case guide >= float64(last):
+			// 超出路径末端后按原速继续
+			takeFrame = anchors[last] + (guide-float64(last))*warpAnchorInterval*speed
+		default:
+			i := int(guide)
+			takeFrame = anchors[i] + (guide-float64(i))*(anchors[i+1]-anchors[i])
+		}
+		return takeFrame * takeSamplesPerFrame
+	})
+}
+
+// ConformToGuide 用 MFCC 上的动态时间规整将录音的节奏对齐到导轨（自动对口型）
+func ConformToGuide(take, guide *audio.AudioSegment) (*audio.AudioSegment, error) {
+	if guide == nil {
+		return nil, errors.New("guide cannot be nil")
+	}
+	path, err := analysis.DTWAlign(guide, take)
+	if err != nil {
+		return nil, err
+	}
+	return TimeWarp(take, path, guide.Duration())
+}
+
+// warpAnchors 平滑规整映射并保证单调，每隔 warpAnchorInterval 帧取一个分段锚点
+func warpAnchors(mapping []float64) []float64 {
+	smoothed := make([]float64, len(mapping))
+	for i := range mapping {
+		lo, hi := max(0, i-warpSmoothing), min(len(mapping)-1, i+warpSmoothing)
+		var sum float64
+		for _, v := range mapping[lo : hi+1] {
+			sum += v
+		}
+		smoothed[i] = sum / float64(hi-lo+1)
+		if i > 0 && smoothed[i] < smoothed[i-1] {
+			smoothed[i] = smoothed[i-1]
+		}
+	}
+
+	var anchors []float64
+	for i := 0; i < len(smoothed); i += warpAnchorInterval {
+		anchors = append(anchors, smoothed[i])
+	}
+	return anchors
+}
+
+// wsola 波形相似叠加：输出第 t 个采样帧取自输入的 position(t) 附近，
+// 每帧在容差范围内搜索与上一帧自然延续最相似的位置，避免相位不连续
+func wsola(segment *audio.AudioSegment, outFrames int, position func(t float64) float64) (*audio.AudioSegment, error) {
+	if outFrames <= 0 {
+		return nil, errors.New("output would be empty")
+	}
+
+	input := segment.SplitChannels()
+	mono := make([]float64, len(input[0]))
+	for _, data := range input {
+		for i, s := range data {
+			mono[i] += s / float64(len(input))
+		}
+	}
+
+	frameSize := max(64, int(wsolaFrameTime*float64(segment.SampleRate()))/2*2)
+	hop := frameSize / 2
+	tolerance := hop / 2
+	window := analysis.Hann(frameSize)
+
+	at := func(i int) float64 {
+		if i < 0 || i >= len(mono) {
+			return 0
+		}
+		return mono[i]
+	}
+
+	output := make([][]float64, len(input))
+	for ch := range output {
+		output[ch] = make([]float64, outFrames)
+	}
+	weights := make([]float64, outFrames)
+
+	previous := 0
+	// 从 -hop 开始，保证开头也有完整的窗重叠
+	for k, out := 0, -hop; out < outFrames; k, out = k+1, out+hop {
+		ideal := int(math.Round(position(float64(out+frameSize/2)))) - frameSize/2
+		start := ideal
+		if k > 0 {
+			// 在 ideal±tolerance 内寻找与上一帧自然延续（previous+hop）重叠部分最相似的位置
+			natural := previous + hop
+			best := math.Inf(-1)
+			for delta := -tolerance; delta <= tolerance; delta++ {
+				candidate := ideal + delta
+				var corr float64
+				for i := 0; i < frameSize-hop; i++ {
+					corr += at(natural+i) * at(candidate+i)
+				}
+				if corr > best {
+					best, start = corr, candidate
+				}
+			}
+		}
+		previous = start
+
+		for i := 0; i < frameSize; i++ {
+			o, src := out+i, start+i
+			if o < 0 || o >= outFrames {
+				continue
+			}
+			weights[o] += window[i]
+			if src < 0 || src >= len(mono) {
+				continue
+			}
+			for ch := range output {
+				output[ch][o] += window[i] * input[ch][src]
+			}
+		}
+	}
+
+	for ch := range output {
+		for i, w := range weights {
+			if w > 1e-6 {
+				output[ch][i] /= w
+			}
+		}
+	}
+
+	result, err := audio.NewAudioSegmentFromChannels(output, segment.SampleRate(), segment.BitDepth())
+	if err != nil {
+		return nil, err
+	}
+	return inherit(segment, result)
+}
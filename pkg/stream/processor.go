@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os/exec"
 	"strconv"
+
+	"github.com/HiChen85/godub/pkg/converter"
 )
 
 // AudioFormat 音频格式
@@ -45,7 +46,7 @@ func NewFFmpegProcessor(format AudioFormat) *FFmpegProcessor {
 // Process 实现 AudioProcessor 接口
 func (p *FFmpegProcessor) Process(data []byte, params AudioParams) ([][]byte, error) {
 	// 创建命令管道用于音频转换
-	ffmpeg := exec.Command("ffmpeg",
+	ffmpeg := converter.FFmpeg.Command(
 		"-f", string(p.inputFormat), // 输入格式
 		"-i", "pipe:0", // 从标准输入读取
 		"-ar", strconv.Itoa(params.SampleRate),
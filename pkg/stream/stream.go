@@ -4,6 +4,7 @@ import (
 	"io"
 
 	"github.com/HiChen85/godub/pkg/audio"
+	"github.com/HiChen85/godub/pkg/converter"
 	"github.com/pkg/errors"
 )
 
@@ -23,6 +24,9 @@ type AudioStream struct {
 
 	// 缓冲区
 	buffer []float64
+
+	// 写入时使用的量化器
+	quantizer *converter.Quantizer
 }
 
 // NewAudioStream 创建一个新的音频流
@@ -51,9 +55,35 @@ func NewAudioStream(reader io.Reader, writer io.Writer, sampleRate, channels, bi
 		reader:     reader,
 		writer:     writer,
 		buffer:     make([]float64, bufferSize),
+		quantizer:  converter.NewQuantizer(bitDepth, channels, converter.DitherNone, converter.NoiseShapingNone),
 	}, nil
 }
 
+// SampleRate 返回采样率
+func (s *AudioStream) SampleRate() int {
+	return s.sampleRate
+}
+
+// Channels 返回声道数
+func (s *AudioStream) Channels() int {
+	return s.channels
+}
+
+// BitDepth 返回位深度
+func (s *AudioStream) BitDepth() int {
+	return s.bitDepth
+}
+
+// BufferSize 返回缓冲区大小（以样本为单位）
+func (s *AudioStream) BufferSize() int {
+	return s.bufferSize
+}
+
+// SetDither 设置写入时使用的抖动和噪声整形方式
+func (s *AudioStream) SetDither(dither converter.DitherType, shaping converter.NoiseShaping) {
+	s.quantizer = converter.NewQuantizer(s.bitDepth, s.channels, dither, shaping)
+}
+
 // Read 从音频流中读取数据
 func (s *AudioStream) Read(p []float64) (n int, err error) {
 	if s.reader == nil {
@@ -107,50 +137,9 @@ func (s *AudioStream) Write(p []float64) (n int, err error) {
 	bytesPerSample := s.bitDepth / 8
 	bytes := make([]byte, len(p)*bytesPerSample)
 
-	// 将float64样本转换为字节
+	// 将float64样本量化为字节（包含限幅与抖动）
 	for i := 0; i < len(p); i++ {
-		// 限制样本值在有效范围内
-		sample := p[i]
-		switch s.bitDepth {
-		case 8:
-			if sample > 1.0 {
-				sample = 1.0
-			} else if sample < -1.0 {
-				sample = -1.0
-			}
-			intSample := int32(sample * 127.0)
-			bytes[i] = byte(intSample + 128)
-		case 16:
-			if sample > 1.0 {
-				sample = 1.0
-			} else if sample < -1.0 {
-				sample = -1.0
-			}
-			intSample := int32(sample * 32767.0)
-			bytes[i*2] = byte(intSample)
-			bytes[i*2+1] = byte(intSample >> 8)
-		case 24:
-			if sample > 1.0 {
-				sample = 1.0
-			} else if sample < -1.0 {
-				sample = -1.0
-			}
-			intSample := int32(sample * 8388607.0)
-			bytes[i*3] = byte(intSample)
-			bytes[i*3+1] = byte(intSample >> 8)
-			bytes[i*3+2] = byte(intSample >> 16)
-		case 32:
-			if sample > 1.0 {
-				sample = 1.0
-			} else if sample < -1.0 {
-				sample = -1.0
-			}
-			intSample := int32(sample * 2147483647.0)
-			bytes[i*4] = byte(intSample)
-			bytes[i*4+1] = byte(intSample >> 8)
-			bytes[i*4+2] = byte(intSample >> 16)
-			bytes[i*4+3] = byte(intSample >> 24)
-		}
+		s.quantizer.PutSample(bytes[i*bytesPerSample:], p[i])
 	}
 
 	// 写入字节到底层writer